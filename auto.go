@@ -0,0 +1,202 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ------------ AUTO
+
+// form is the state the scripts of a template are in
+type form int
+
+const (
+	// noForm templates have no brace either parse would change
+	noForm form = iota
+
+	// bareForm templates have bare braces, the delim parse leaves them
+	bareForm
+
+	// escapedForm templates have escaped braces, the brace parse leaves
+	// them
+	escapedForm
+
+	// mixedForm templates have both, each parse would change them
+	mixedForm
+)
+
+var autoTargets = map[string]form{
+	"bare":    bareForm,
+	"escaped": escapedForm,
+}
+
+// detectForm tells the form of src from the left braces of its script
+// code, so it does not depend on how either parse would escape them. The
+// results of both parses are returned along with it
+func detectForm(src []byte, opts options) (form, []byte, []byte, error) {
+	var escaped, bare bytes.Buffer
+
+	if err := parseBraces(bytes.NewReader(src), &escaped, opts); err != nil {
+		return noForm, nil, nil, err
+	}
+
+	if err := parseDelims(bytes.NewReader(src), &bare, opts); err != nil {
+		return noForm, nil, nil, err
+	}
+
+	bareBraces, escapeTags := opts.dialect.braceCounts(src)
+
+	switch {
+	case bareBraces > 0 && escapeTags > 0:
+		return mixedForm, escaped.Bytes(), bare.Bytes(), nil
+	case escapeTags > 0:
+		return escapedForm, escaped.Bytes(), bare.Bytes(), nil
+	case bareBraces > 0:
+		return bareForm, escaped.Bytes(), bare.Bytes(), nil
+	}
+
+	return noForm, escaped.Bytes(), bare.Bytes(), nil
+}
+
+// braceCounts counts the bare left braces and the left escape tags of the
+// script code of src. Strings, template literal text, comments, Smarty tags,
+// PHP regions, literal blocks and ignored lines are left out, as are the
+// braces followed by whitespace on an autoLiteral dialect, which both forms
+// have
+func (d dialect) braceCounts(src []byte) (int, int) {
+	var bare, escaped int
+	var insideScriptTag bool
+	var insideLiteralTag bool
+	var insidePHPTag bool
+	var insideHTMLComment bool
+	var insideSmartyComment bool
+	var directives directiveState
+
+	s := &jsScanner{dialect: d}
+	tags := d.withCanonical()
+
+	for _, line := range splitLines(string(src)) {
+		if directives.ignores(line) || insidePHPTag && !endOfPHPTag(line) {
+			continue
+		}
+
+		line, _, insidePHPTag = maskPHPLine(line, insidePHPTag)
+
+		if !insideScriptTag {
+			var html string
+
+			html, insideSmartyComment = stripSmartyComments(line, insideSmartyComment)
+			html, insideHTMLComment = stripHTMLComments(html, insideHTMLComment)
+			insideScriptTag = startOfScriptTag(html)
+			insideHTMLComment = insideHTMLComment && !insideScriptTag
+			insideSmartyComment = insideSmartyComment && !insideScriptTag
+
+			if !insideScriptTag {
+				continue
+			}
+		}
+
+		if !insideLiteralTag {
+			insideLiteralTag = d.startOfLiteralTag(line)
+		}
+
+		// the scanner follows literal blocks as well so its state is right
+		// once they end
+		if insideLiteralTag {
+			insideLiteralTag = !d.endOfLiteralTag(line)
+			s.scan(line)
+			continue
+		}
+
+		var code string
+
+		// the other segments are blanked out, a brace followed by them
+		// being no less bare
+		for _, seg := range s.scan(d.dropInlineLiterals(line)) {
+			if seg.kind != codeSegment {
+				code += strings.Repeat("_", len(seg.text))
+				continue
+			}
+
+			code += seg.text
+		}
+
+		for i := 0; i < len(code); i++ {
+			if code[i] != '{' {
+				continue
+			}
+
+			if token, tag, ok := tags.escapeTagAt(code[i:]); ok {
+				if token == leftDelim {
+					escaped++
+				}
+
+				i += len(tag) - 1
+				continue
+			}
+
+			if !d.autoLiteral || i+1 < len(code) && !isSpace(code[i+1]) {
+				bare++
+			}
+		}
+
+		insideScriptTag = !endOfScriptTag(line)
+	}
+
+	return bare, escaped
+}
+
+// dropInlineLiterals returns line without the literal blocks opened and
+// closed within it
+func (d dialect) dropInlineLiterals(line string) string {
+	var nLine string
+	var inside bool
+
+	for _, part := range d.splitLiteralTags(line) {
+		switch {
+		case part == d.literal[0] && !inside:
+			inside = true
+		case inside:
+			inside = part != d.literal[1]
+		default:
+			nLine += part
+		}
+	}
+
+	return nLine
+}
+
+// autoParse converts the template read from input towards the target form,
+// a template mixing both forms being reported and written unchanged
+func autoParse(input io.Reader, output io.Writer, path string, report io.Writer, target string, opts options) error {
+	src, err := ioutil.ReadAll(input)
+	if err != nil {
+		return err
+	}
+
+	f, escaped, bare, err := detectForm(src, opts)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case f == mixedForm:
+		fmt.Fprintf(report, "Mixed bare and escaped braces, left for review: %s\n", path)
+		_, err = output.Write(src)
+	case f == noForm || f == autoTargets[target]:
+		_, err = output.Write(src)
+	case autoTargets[target] == escapedForm:
+		_, err = output.Write(escaped)
+	default:
+		_, err = output.Write(bare)
+	}
+
+	return err
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDetectForm(t *testing.T) {
+	files := []string{
+		"files/simple_brace.tpl",
+		"files/simple_delim.tpl",
+		"files/simple_delim_quote.tpl",
+		"files/auto_mixed.tpl",
+	}
+
+	expected := []form{bareForm, escapedForm, escapedForm, mixedForm}
+
+	for i, f := range files {
+		src, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, _, _, err := detectForm(src, defaultOptions())
+		if err != nil {
+			t.Fatalf("Expected error to be nil; got: %s", err)
+		}
+
+		if got != expected[i] {
+			t.Fatalf("Expected form %d for %s; got: %d", expected[i], f, got)
+		}
+	}
+
+	if got, _, _, _ := detectForm([]byte("<p>{$title}</p>\n"), defaultOptions()); got != noForm {
+		t.Fatalf("Expected form %d without scripts; got: %d", noForm, got)
+	}
+
+	// the strategy the parses would use makes no other form
+	opts := defaultOptions()
+	opts.strategy = "literal"
+
+	src, err := ioutil.ReadFile("files/simple_delim.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _, _, _ := detectForm(src, opts); got != escapedForm {
+		t.Fatalf("Expected form %d with the literal strategy; got: %d", escapedForm, got)
+	}
+}
+
+func TestBraceCounts(t *testing.T) {
+	src := []byte(`<p>{$title}</p>
+<script>
+var a = {ldelim}b: "{c}"{rdelim}, d = {$smarty.ldelim}{rdelim} // {e}
+var f = {literal}{g: 1}{/literal}, h = {$i}, j = { k: 1 }
+{literal}
+var l = {m: 1}
+{/literal}
+var n = {o: 1}
+</script>
+`)
+
+	if bare, escaped := dialects["smarty2"].braceCounts(src); bare != 2 || escaped != 2 {
+		t.Fatalf("Expected 2 bare braces and 2 escape tags; got: %d %d", bare, escaped)
+	}
+
+	if bare, escaped := dialects["smarty3"].braceCounts(src); bare != 1 || escaped != 2 {
+		t.Fatalf("Expected 1 bare brace and 2 escape tags; got: %d %d", bare, escaped)
+	}
+}
+
+func TestAutoParse(t *testing.T) {
+	bare, _ := ioutil.ReadFile("files/simple_brace.tpl")
+	escaped, _ := ioutil.ReadFile("files/simple_delim.tpl")
+	roundtrip, _ := ioutil.ReadFile("files/simple_brace_roundtrip.tpl")
+	mixed, _ := ioutil.ReadFile("files/auto_mixed.tpl")
+
+	cases := []struct {
+		src    []byte
+		target string
+		exp    []byte
+	}{
+		{bare, "escaped", escaped},
+		{escaped, "escaped", escaped},
+		{escaped, "bare", roundtrip},
+		{bare, "bare", bare},
+		{mixed, "escaped", mixed},
+		{mixed, "bare", mixed},
+	}
+
+	for _, c := range cases {
+		var output, report bytes.Buffer
+
+		if err := autoParse(bytes.NewReader(c.src), &output, "x.tpl", &report, c.target, defaultOptions()); err != nil {
+			t.Fatalf("Expected error to be nil; got: %s", err)
+		}
+
+		if !bytes.Equal(output.Bytes(), c.exp) {
+			t.Fatalf("Expected %s output:\n%s\ngot:\n%s", c.target, c.exp, output.Bytes())
+		}
+
+		isMixed := bytes.Equal(c.src, mixed)
+		expReport := "Mixed bare and escaped braces, left for review: x.tpl\n"
+
+		if isMixed && report.String() != expReport {
+			t.Fatalf("Expected report: %s; got: %s", expReport, report.String())
+		}
+
+		if !isMixed && report.Len() != 0 {
+			t.Fatalf("Expected no report; got: %s", report.String())
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -20,7 +21,8 @@ func createBackup(path, suffix string, overwrite bool) (string, error) {
 	}
 
 	name := f.Name()
-	outputName := name[:strings.Index(name, ".tpl")] + suffix + ".tpl"
+	ext := filepath.Ext(name)
+	outputName := strings.TrimSuffix(name, ext) + suffix + ext
 
 	_, err = os.Stat(outputName)
 	if !os.IsNotExist(err) && !overwrite {
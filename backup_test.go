@@ -82,3 +82,18 @@ func TestBackup(t *testing.T) {
 		t.Fatalf("Expected filesize: %d; got: %d", inputStat.Size(), backupStat.Size())
 	}
 }
+
+func TestBackupExtension(t *testing.T) {
+	path := "files/latte_brace.latte"
+	expBackup := "files/latte_brace_backup.latte"
+
+	backup, err := createBackup(path, "_backup", true)
+	if err != nil {
+		t.Fatalf("Error during backup: %s", err)
+	}
+	defer os.Remove(backup)
+
+	if backup != expBackup {
+		t.Fatalf("Expected backup name: %s; got : %s", expBackup, backup)
+	}
+}
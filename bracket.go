@@ -4,79 +4,19 @@
 
 package main
 
-import (
-	"regexp"
-	"strings"
-)
-
-// ------------ LEFT BRACE
-func parseLeftBrace(line string) (string, bool) {
-	var nLine string
-	re := `(.*)(\{)((.*)(\}))?(.*)`
-	matches := regexp.MustCompile(re).FindStringSubmatch(line)
-
-	if len(matches) != 7 {
-		return line, false
-	}
-
-	if matches[1] != "" {
-		nLine, _ = parseLeftBrace(matches[1])
-	}
-
-	var matchDelim bool
-
-	if matches[2] == "{" {
-		if matches[5] != "}" {
-			matchDelim = true
-			nLine += "{ldelim}"
-		} else if matches[5] == "}" && matches[4] == "" {
-			matchDelim = true
-			nLine += "{ldelim}"
-		}
-	}
-
-	if !matchDelim {
-		nLine += matches[2]
-	}
-
-	return nLine + matches[3] + matches[6], matchDelim
-}
-
-// ------------ RIGHT BRACE
-func parseRightBrace(line string) (string, bool) {
-	var nLine string
-	re := `(.*)((\{)(.*))(\})(.*)`
-	matches := regexp.MustCompile(re).FindStringSubmatch(line)
-
-	if len(matches) == 7 {
-		// take first regex actions
-
-		if matches[1] != "" {
-			nLine, _ = parseRightBrace(matches[1])
-		}
-
-		if matches[5] == "}" {
-			hasLeft := matches[3] == "{"
-			if hasLeft && (strings.Contains(matches[4], "}") || matches[4] == "") {
-				nLine += matches[2] + "{rdelim}"
-			} else if hasLeft {
-				nLine += matches[2] + matches[5]
-			}
-		}
-
-		return nLine + matches[6], true
-	}
-
-	re = `(.*)(})(.*)`
-	matches = regexp.MustCompile(re).FindStringSubmatch(line)
-
-	if len(matches) != 4 {
-		return line, false
-	}
-
-	if matches[1] != "" {
-		nLine, _ = parseRightBrace(matches[1])
-	}
-
-	return nLine + "{rdelim}" + matches[3], true
+// ------------ ESCAPE BRACES
+
+// escapeBraces escapes every brace of script code which does not belong to
+// a Smarty tag or an escape tag. Braces are not told apart by the shape of
+// the JS around them, so destructuring, shorthand properties, class bodies
+// and arrow function bodies are all escaped alike. Literal blocks opened
+// and closed within line are left as they are, as are the escape tags of
+// the dialect, the braces being escaped to the canonical tags
+func (d dialect) escapeBraces(line string) string {
+	c := d.withCanonical()
+	c.autoLiteral = false
+
+	return d.outsideInlineLiterals(line, func(text string) string {
+		return c.escapeText(text, "")
+	})
 }
@@ -6,150 +6,34 @@ package main
 
 import "testing"
 
-// ------------ LEFT BRACE
-
-var leftBrace = []string{
-	"function () {",
-	"call({",
-	"myObject: { // random comment",
-	"let array = [{",
-	"{",
-	"calling({my: {",
-	"const single = {}",
-	"{}",
-}
-
-var expLeftBrace = []string{
-	"function () {ldelim}",
-	"call({ldelim}",
-	"myObject: {ldelim} // random comment",
-	"let array = [{ldelim}",
-	"{ldelim}",
-	"calling({ldelim}my: {ldelim}",
-	"const single = {ldelim}}",
-	"{ldelim}}",
-}
-
-var nonLeftBrace = []string{
-	`<body>`,
-	`{$some_variable}`,
-	`Outside the script tag may be pure html or may not`,
-	`<script type="text/javascript">`,
-	`let myVar = {json_decode($jsonVariable)}`,
-	`let myOtherVar = '{$wuuuu}'`,
-	`console.log({include file=$myCustomFile})`,
-	`const myObject = {hello: "world", myObject:{one: 1, two: [2, 2]}}`,
-	`}`,
-	`hello: "world"`,
-	`world: "hello"`,
-	`})`,
-	`hello: "world",`,
-	`one: 1,`,
-	`two: [2, 2]`,
-	`}`,
-	`}]`,
-	`</script>`,
-	`</body>`,
-}
-
-func TestParseLeftBraceMatch(t *testing.T) {
-	for i, line := range leftBrace {
-		nl, matched := parseLeftBrace(line)
-
-		if !matched {
-			t.Fatalf("Should match %s", line)
-		}
-
-		if nl != expLeftBrace[i] {
-			t.Fatalf("Expected left brace parsed: %s; got: %s", expLeftBrace[i], nl)
-		}
+// ------------ ESCAPE BRACES
+
+func TestEscapeBraces(t *testing.T) {
+	lines := []string{
+		`const {a, b: {c}, ...rest} = {$config|json_encode}`,
+		`class Store extends Base { #items = {} }`,
+		`const add = (x, y) => { return {x, y} }`,
+		`const merged = {...defaults, [key]: {ldelim}{rdelim}, {include file="x.tpl"}}`,
+		`var x = {literal}{a: 1}{/literal}; var conv = {};`,
+		`var s = "{'{'}" + {a: "{$smarty.rdelim}"}`,
 	}
-}
-
-func TestParseLeftBraceNoMatch(t *testing.T) {
-	for _, line := range nonLeftBrace {
-		nl, matched := parseLeftBrace(line)
 
-		if matched && nl != line {
-			t.Fatalf("Should not match %s; %s", line, nl)
-		}
+	expected := []string{
+		`const {ldelim}a, b: {ldelim}c{rdelim}, ...rest{rdelim} = {$config|json_encode}`,
+		`class Store extends Base {ldelim} #items = {ldelim}{rdelim} {rdelim}`,
+		`const add = (x, y) => {ldelim} return {ldelim}x, y{rdelim} {rdelim}`,
+		`const merged = {ldelim}...defaults, [key]: {ldelim}{rdelim}, {include file="x.tpl"}{rdelim}`,
+		`var x = {literal}{a: 1}{/literal}; var conv = {ldelim}{rdelim};`,
+		`var s = "{'{'}" + {ldelim}a: "{$smarty.rdelim}"{rdelim}`,
 	}
-}
-
-// ------------ RIGHT BRACE
-
-var rightBrace = []string{
-	`everthing }`,
-	`}, { should`,
-	`be }) good`,
-	`or }] not?`,
-	`... maybe }] }?`,
-	`}, {ldelim}`,
-	`}, maybe: ""}, did: "not"}, work: {"entirely"}`,
-	`}, maybe: ""}, did: "not"}, work: {"entir}ely"}`,
-	"const single = {}",
-	"{}",
-}
-
-var expRightBrace = []string{
-	`everthing {rdelim}`,
-	`{rdelim}, { should`,
-	`be {rdelim}) good`,
-	`or {rdelim}] not?`,
-	`... maybe {rdelim}] {rdelim}?`,
-	`{rdelim}, {ldelim}`,
-	`{rdelim}, maybe: ""{rdelim}, did: "not"{rdelim}, work: {"entirely"}`,
-	`{rdelim}, maybe: ""{rdelim}, did: "not"{rdelim}, work: {"entir}ely"{rdelim}`,
-	"const single = {{rdelim}",
-	"{{rdelim}",
-}
-
-var nonRightBrace = []string{
-	`<body>`,
-	`{$some_variable}`,
-	`Outside the script tag may be pure html or may not`,
-	`<script type="text/javascript">`,
-	`let myVar = {json_decode($jsonVariable)}`,
-	`let myOtherVar = '{$wuuuu}'`,
-	`console.log({include file=$myCustomFile})`,
-	`funcion () {`,
-	`const myObject = {hello: "world", myObject:{one: 1, two: [2, 2]}`,
-	`call({`,
-	`hello: "world"`,
-	`, {`,
-	`world: "hello"`,
-	`)`,
-	`let array = [{`,
-	`hello: "world",`,
-	`myObject:{`,
-	`one: 1,`,
-	`two: [2, 2] `,
-	`]`,
-	`inline_call({hello: "world", myObject:{one: 1, two: [2, 2]})`,
-	`</script>`,
-	`</body>`,
-}
-
-func TestParseRightBrace(t *testing.T) {
-	for i, line := range rightBrace {
-		nl, matched := parseRightBrace(line)
-
-		if !matched {
-			t.Fatalf("Should match: %s", line)
-		}
 
-		if nl != expRightBrace[i] {
-			t.Fatalf("Expected right brace parsed: %s; got: %s", expRightBrace[i], nl)
+	for i, line := range lines {
+		if r := dialects["smarty2"].escapeBraces(line); r != expected[i] {
+			t.Fatalf("Expected braces escaped: %s; got: %s", expected[i], r)
 		}
 	}
-}
-
-func TestParseRightBraceNoMatch(t *testing.T) {
-	for _, line := range nonRightBrace {
-		nl, matched := parseRightBrace(line)
 
-		if matched && nl != line {
-			t.Fatalf("Should not match %s; %s", line, nl)
-		}
+	if r := dialects["latte"].escapeBraces(`var s = "{l}" + {}`); r != `var s = "{l}" + {ldelim}{rdelim}` {
+		t.Fatalf("Expected latte escape tags to be kept; got: %s", r)
 	}
 }
@@ -10,35 +10,32 @@ import (
 	"strings"
 )
 
+// commentSegments splits line into segments so comment markers within
+// strings, template or regex literals are not taken for comments
+func commentSegments(line string) []segment {
+	s := &jsScanner{}
+
+	return s.scan(strings.TrimSuffix(line, "\n"))
+}
+
 func isCommentLine(line string) bool {
-	return regexp.MustCompile(`(.*)\/\/(.*)`).MatchString(line)
+	_, matched := parseCommentLine(line)
+
+	return matched
 }
 
 func parseCommentLine(line string) ([]string, bool) {
 	var nLine string
-	var rc string
-	re := regexp.MustCompile(`(.*)(\/\/.*)`)
-	matches := re.FindStringSubmatch(line)
-
-	if len(matches) != 3 {
-		return []string{nLine, line}, false
-	}
-
-	if matches[1] != "" {
-		lMatches, matched := parseCommentLine(matches[1])
 
-		nLine = lMatches[1]
-		rc = matches[2]
-
-		if matched {
-			nLine = lMatches[0]
-			rc = lMatches[1] + matches[2]
+	for _, seg := range commentSegments(line) {
+		if seg.kind == lineCommentSegment {
+			return []string{nLine, strings.TrimSuffix(line[len(nLine):], "\n")}, true
 		}
 
-		return []string{nLine, rc}, true
+		nLine += seg.text
 	}
 
-	return []string{nLine, matches[2]}, true
+	return []string{"", line}, false
 }
 
 // -------------- single Multiline
@@ -55,35 +52,21 @@ func isMultilineCommentStart(line string, single bool) bool {
 
 func parseMultilineCommentStart(line string, single bool) ([]string, bool) {
 	var nLine string
-	var rc string
-	p := `(.*)(\/\*\*.*)`
+	kind, open := blockCommentSegment, "/**"
 
 	if !single {
-		p = `(.*)(\{\*.*)`
+		kind, open = smartyCommentSegment, "{*"
 	}
 
-	re := regexp.MustCompile(p)
-	matches := re.FindStringSubmatch(line)
-
-	if len(matches) != 3 {
-		return []string{nLine, line}, false
-	}
-
-	if matches[1] != "" {
-		lMatches, matched := parseMultilineCommentStart(matches[1], single)
-
-		nLine = lMatches[1]
-		rc = matches[2]
-
-		if matched {
-			nLine = lMatches[0]
-			rc = lMatches[1] + matches[2]
+	for _, seg := range commentSegments(line) {
+		if seg.kind == kind && strings.HasPrefix(seg.text, open) {
+			return []string{nLine, seg.text}, true
 		}
 
-		return []string{nLine, rc}, true
+		nLine += seg.text
 	}
 
-	return []string{nLine, matches[2]}, true
+	return []string{"", line}, false
 }
 
 func isMultilineCommentEnd(line string, single bool) bool {
@@ -131,40 +114,39 @@ func parseMultilineCommentEnd(line string, single bool) ([]string, bool) {
 
 // ------------- Comment Fragments
 
+// parseCommentFragmets replaces the comments opened and closed within line
+// by [FCT-n] fragments, numbering the JS ones before the Smarty ones
 func parseCommentFragmets(line string) (string, []string) {
-	nLine := line
+	var nLine string
 	var match []string
 
-	re := regexp.MustCompile(`(.*?)(\/\*.*?\*\/)(.*)`)
-	for {
-		matches := re.FindStringSubmatch(nLine)
+	body := strings.TrimSuffix(line, "\n")
+	segs := commentSegments(body)
 
-		if matches == nil {
-			break
+	for _, kind := range []segmentKind{blockCommentSegment, smartyCommentSegment} {
+		for i, seg := range segs {
+			if seg.kind == kind && isClosedComment(seg) {
+				segs[i].text = "[FCT-" + strconv.Itoa(len(match)) + "]"
+				segs[i].kind = codeSegment
+				match = append(match, seg.text)
+			}
 		}
-
-		nLine = matches[1] + matches[3]
-		match = append(match, matches[2])
 	}
 
-	nLine = line
-	re = regexp.MustCompile(`(.*?)(\{\*.*?\*\})(.*)`)
-	for {
-		matches := re.FindStringSubmatch(nLine)
-
-		if matches == nil {
-			break
-		}
-
-		nLine = matches[1] + matches[3]
-		match = append(match, matches[2])
+	for _, seg := range segs {
+		nLine += seg.text
 	}
 
-	if len(match) > 0 {
-		for i, v := range match {
-			line = strings.Replace(line, v, "[FCT-"+strconv.Itoa(i)+"]", 1)
-		}
+	return nLine + line[len(body):], match
+}
+
+func isClosedComment(seg segment) bool {
+	switch seg.kind {
+	case blockCommentSegment:
+		return len(seg.text) >= 4 && strings.HasSuffix(seg.text, "*/")
+	case smartyCommentSegment:
+		return len(seg.text) >= 4 && strings.HasSuffix(seg.text, "*}")
 	}
 
-	return line, match
+	return false
 }
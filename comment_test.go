@@ -498,3 +498,47 @@ func TestParseCommentFragmentNoMatch(t *testing.T) {
 		}
 	}
 }
+
+var stringCommentLines = []string{
+	`var url = "http://example.com/{$path}"`,
+	`var url = 'http://example.com/' + path // {$path}`,
+	"var url = `http://example.com/${path}` // {$path}",
+	`var glob = "src/**/*.js", x = {a: 1} /* {b} */`,
+	`var quote = "it's \"//\" here" + {$b}`,
+}
+
+var expStringCommentLines = [][]string{
+	[]string{``, `var url = "http://example.com/{$path}"`},
+	[]string{`var url = 'http://example.com/' + path `, `// {$path}`},
+	[]string{"var url = `http://example.com/${path}` ", `// {$path}`},
+	[]string{``, `var glob = "src/**/*.js", x = {a: 1} /* {b} */`},
+	[]string{``, `var quote = "it's \"//\" here" + {$b}`},
+}
+
+var expStringCommentFragments = []string{
+	`var url = "http://example.com/{$path}"`,
+	`var url = 'http://example.com/' + path // {$path}`,
+	"var url = `http://example.com/${path}` // {$path}",
+	`var glob = "src/**/*.js", x = {a: 1} [FCT-0]`,
+	`var quote = "it's \"//\" here" + {$b}`,
+}
+
+func TestCommentWithinStrings(t *testing.T) {
+	for i, c := range stringCommentLines {
+		left, _ := parseCommentLine(c)
+
+		if left[0] != expStringCommentLines[i][0] || left[1] != expStringCommentLines[i][1] {
+			t.Fatalf("Expected line comment parse: %v; got: %v", expStringCommentLines[i], left)
+		}
+
+		if l, _ := parseCommentFragmets(c); l != expStringCommentFragments[i] {
+			t.Fatalf("Expected fragments parse: %s; got: %s", expStringCommentFragments[i], l)
+		}
+	}
+
+	left, match := parseMultilineCommentStart(`var s = "/** {$a}", t = 1 /** start`, true)
+
+	if !match || left[0] != `var s = "/** {$a}", t = 1 ` {
+		t.Fatalf("Expected multiline start after the string; got: %v", left)
+	}
+}
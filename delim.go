@@ -4,51 +4,245 @@
 
 package main
 
-import (
-	"regexp"
-	"strings"
-)
+import "strings"
 
 // ------------ LDELIM
 func parseLDelim(line string) (string, bool, bool) {
-	if !strings.Contains(line, "{ldelim}") {
+	return replaceOutsideStrings(line, "{ldelim}", "{")
+}
+
+// ------------ RDELIM
+func parseRDelim(line string) (string, bool, bool) {
+	return replaceOutsideStrings(line, "{rdelim}", "}")
+}
+
+// replaceOutsideStrings replaces tag by brace in line except within quoted
+// strings and template literals, which follow the JS escape rules. It tells
+// whether tag was replaced and whether the line holds any string
+func replaceOutsideStrings(line, tag, brace string) (string, bool, bool) {
+	if !strings.Contains(line, tag) {
 		return line, false, false
 	}
 
-	chars := []string{`"`, `'`, "`"}
-	for _, c := range chars {
-		p := `(.*?)([\` + c + `](.*?(\{ldelim\})?.*?)[\` + c + `])(.*)`
-		matches := regexp.MustCompile(p).FindStringSubmatch(line)
+	var nLine string
+	var matched bool
+	var split bool
 
-		if matches != nil {
-			l, lm, _ := parseLDelim(matches[1])
-			r, rm, _ := parseLDelim(matches[5])
+	s := &jsScanner{dialect: canonical}
 
-			return l + matches[2] + r, lm || rm, true
+	for _, seg := range s.scan(line) {
+		switch seg.kind {
+		case stringSegment, templateSegment:
+			split = true
+		case codeSegment, templateOpenSegment, templateCloseSegment:
+			if strings.Contains(seg.text, tag) {
+				seg.text = strings.Replace(seg.text, tag, brace, -1)
+				matched = true
+			}
 		}
+
+		nLine += seg.text
 	}
 
-	return strings.Replace(line, "{ldelim}", "{", -1), true, false
+	return nLine, matched, split
 }
 
-// ------------ RDELIM
-func parseRDelim(line string) (string, bool, bool) {
-	if !strings.Contains(line, "{rdelim}") {
-		return line, false, false
+// ------------ ESCAPE TAGS
+
+// escapeTags are a pair of tags an engine renders as a left and right brace
+type escapeTags struct {
+	Style string `json:"style"`
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+type delimToken int
+
+const (
+	leftDelim delimToken = iota
+	rightDelim
+	leftBareBrace
+	rightBareBrace
+)
+
+// mapDelims calls fn for every escape tag and bare brace of line outside a
+// quoted string, replacing it with what fn returns, rest is the line after it
+func (d dialect) mapDelims(line string, fn func(token delimToken, text, rest string) string) string {
+	return d.mapQuotedDelims(line, fn, nil)
+}
+
+// mapQuotedDelims is mapDelims calling quoted as well, when not nil, for
+// every escape tag within a quoted string, quote being the one opening it
+func (d dialect) mapQuotedDelims(line string, fn func(token delimToken, text, rest string) string, quoted func(quote byte, token delimToken, text string) string) string {
+	var nLine string
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		if quote != 0 {
+			if token, text, ok := d.escapeTagAt(line[i:]); ok && quoted != nil {
+				nLine += quoted(quote, token, text)
+				i += len(text) - 1
+				continue
+			}
+
+			if c == '\\' && i+1 < len(line) {
+				nLine += line[i : i+2]
+				i++
+				continue
+			} else if c == quote {
+				quote = 0
+			}
+
+			nLine += string(c)
+			continue
+		}
+
+		if c == '{' {
+			if token, text, ok := d.escapeTagAt(line[i:]); ok {
+				nLine += fn(token, text, line[i+len(text):])
+				i += len(text) - 1
+				continue
+			}
+
+			nLine += fn(leftBareBrace, "{", line[i+1:])
+			continue
+		}
+
+		switch c {
+		case '}':
+			nLine += fn(rightBareBrace, "}", line[i+1:])
+		case '"', '\'', '`':
+			quote = c
+			nLine += string(c)
+		default:
+			nLine += string(c)
+		}
 	}
 
-	chars := []string{`"`, `'`, "`"}
-	for _, c := range chars {
-		p := `(.*?)([\` + c + `](.*?(\{rdelim\})?.*?)[\` + c + `])(.*)`
-		matches := regexp.MustCompile(p).FindStringSubmatch(line)
+	return nLine
+}
 
-		if matches != nil {
-			l, lm, _ := parseRDelim(matches[1])
-			r, rm, _ := parseRDelim(matches[5])
+func (d dialect) escapeTagAt(line string) (delimToken, string, bool) {
+	for _, e := range d.escapes {
+		if strings.HasPrefix(line, e.Left) {
+			return leftDelim, e.Left, true
+		}
 
-			return l + matches[2] + r, lm || rm, true
+		if strings.HasPrefix(line, e.Right) {
+			return rightDelim, e.Right, true
 		}
 	}
 
-	return strings.Replace(line, "{rdelim}", "}", -1), true, false
+	return leftDelim, "", false
+}
+
+// escapeStyle returns the tags emitted for style, the first of the dialect
+// when style is empty
+func (d dialect) escapeStyle(style string) (escapeTags, bool) {
+	for _, e := range d.escapes {
+		if style == "" || e.Style == style {
+			return e, true
+		}
+	}
+
+	return escapeTags{}, false
+}
+
+func (d dialect) escapeStyleOf(text string) string {
+	for _, e := range d.escapes {
+		if text == e.Left || text == e.Right {
+			return e.Style
+		}
+	}
+
+	return ""
+}
+
+// canonicalDelims rewrites every escape tag outside quoted strings to
+// {ldelim} or {rdelim} so the parsers only have one form to deal with
+func (d dialect) canonicalDelims(line string) string {
+	return d.mapDelims(line, func(token delimToken, text, rest string) string {
+		switch token {
+		case leftDelim:
+			return "{ldelim}"
+		case rightDelim:
+			return "{rdelim}"
+		}
+
+		return text
+	})
+}
+
+// restyleDelims rewrites every canonical {ldelim} and {rdelim} of line to
+// the tags of style, within quoted strings every escape tag of the dialect
+// as well when style is given. There the first tags of style not holding
+// the quote of the string are used, the tag being left when there is none
+func (d dialect) restyleDelims(line, style string) string {
+	tags, ok := d.escapeStyle(style)
+
+	if !ok || (style == "" && tags.Left == "{ldelim}" && tags.Right == "{rdelim}") {
+		return line
+	}
+
+	pick := func(tags escapeTags, token delimToken) string {
+		if token == leftDelim {
+			return tags.Left
+		}
+
+		return tags.Right
+	}
+
+	return d.withCanonical().mapQuotedDelims(line, func(token delimToken, text, rest string) string {
+		if token == leftDelim || token == rightDelim {
+			return pick(tags, token)
+		}
+
+		return text
+	}, func(quote byte, token delimToken, text string) string {
+		if style == "" && text != "{ldelim}" && text != "{rdelim}" {
+			return text
+		}
+
+		for _, e := range d.escapes {
+			if e.Style == tags.Style && !strings.ContainsRune(e.Left+e.Right, rune(quote)) {
+				return pick(e, token)
+			}
+		}
+
+		return text
+	})
+}
+
+// withCanonical returns d knowing {ldelim} and {rdelim} ahead of its own
+// escape tags
+func (d dialect) withCanonical() dialect {
+	d.escapes = append(append([]escapeTags{}, canonical.escapes...), d.escapes...)
+
+	return d
+}
+
+// hasEscapeTag tells whether line holds any escape tag, quoted or not
+func (d dialect) hasEscapeTag(line string) bool {
+	for _, e := range d.escapes {
+		if strings.Contains(line, e.Left) || strings.Contains(line, e.Right) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stripEscapeTags removes every escape tag of line returning how many
+func (d dialect) stripEscapeTags(line string) (string, int) {
+	var count int
+
+	for _, e := range d.escapes {
+		count += strings.Count(line, e.Left) + strings.Count(line, e.Right)
+		line = strings.Replace(line, e.Left, "", -1)
+		line = strings.Replace(line, e.Right, "", -1)
+	}
+
+	return line, count
 }
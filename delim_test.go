@@ -18,6 +18,9 @@ var lDelims = []string{
 	`const strangeObject = {ldelim}maybe: {ldelim}it: {ldelim}wont: "{ldelim}work: ?"`,
 	`inline_call({ldelim}hello: "world", myObject:{ldelim}one: 1, two: [2, 2]{rdelim}{rdelim})`,
 	`object = {ldelim}left: ["{lrdelim}", "{rdelim}"], right: {ldelim}"{rdelim}", "{ldelim}"{rdelim}{rdelim}`,
+	`msg = {ldelim}title: 'It\'s {ldelim}draft', body: "Say \"{ldelim}\" {$a}"`,
+	`title = 'It\'s {$title|escape:'javascript'} {ldelim}' + {ldelim}a: 1`,
+	`path = 'C:\\' + {ldelim}dir: "{$dir}"`,
 }
 
 var expLDelims = []string{
@@ -30,6 +33,9 @@ var expLDelims = []string{
 	`const strangeObject = {maybe: {it: {wont: "{ldelim}work: ?"`,
 	`inline_call({hello: "world", myObject:{one: 1, two: [2, 2]{rdelim}{rdelim})`,
 	`object = {left: ["{lrdelim}", "{rdelim}"], right: {"{rdelim}", "{ldelim}"{rdelim}{rdelim}`,
+	`msg = {title: 'It\'s {ldelim}draft', body: "Say \"{ldelim}\" {$a}"`,
+	`title = 'It\'s {$title|escape:'javascript'} {ldelim}' + {a: 1`,
+	`path = 'C:\\' + {dir: "{$dir}"`,
 }
 
 var nonLDelims = []string{
@@ -59,6 +65,7 @@ var nonLDelims = []string{
 	`console.log('{rdelim}')`,
 	`console.log("{ldelim}")`,
 	"object.call('{rdelim}', \"{ldelim}\", `{ldelim} & {rdelim}`)",
+	`alert('It\'s {ldelim}', "\"{ldelim}\"")`,
 }
 
 func TestParseLDelimMatch(t *testing.T) {
@@ -163,3 +170,67 @@ func TestParseRDelimNotMatch(t *testing.T) {
 		}
 	}
 }
+
+// ------------ ESCAPE STYLES
+
+var styledDelims = []string{
+	`funcion () {$smarty.ldelim}`,
+	`const myObject = {'{'}hello: "world", myObject:{"{"}one: 1{"}"}{'}'}`,
+	`{$smarty.rdelim}, {ldelim}`,
+	`console.log('{$smarty.rdelim}', "{'{'}")`,
+	`let myOtherVar = '{$wuuuu}'`,
+}
+
+var expCanonicalDelims = []string{
+	`funcion () {ldelim}`,
+	`const myObject = {ldelim}hello: "world", myObject:{ldelim}one: 1{rdelim}{rdelim}`,
+	`{rdelim}, {ldelim}`,
+	`console.log('{$smarty.rdelim}', "{'{'}")`,
+	`let myOtherVar = '{$wuuuu}'`,
+}
+
+func TestCanonicalDelims(t *testing.T) {
+	for i, line := range styledDelims {
+		if nl := dialects["smarty2"].canonicalDelims(line); nl != expCanonicalDelims[i] {
+			t.Fatalf("Expected canonical delims: %s; got: %s", expCanonicalDelims[i], nl)
+		}
+	}
+}
+
+func TestRestyleDelims(t *testing.T) {
+	line := `call({ldelim}a: "{ldelim}"{rdelim})`
+	exp := map[string]string{
+		"delim":  `call({ldelim}a: "{ldelim}"{rdelim})`,
+		"smarty": `call({$smarty.ldelim}a: "{$smarty.ldelim}"{$smarty.rdelim})`,
+		"quote":  `call({'{'}a: "{'{'}"{'}'})`,
+	}
+
+	for style, e := range exp {
+		if nl := dialects["smarty2"].restyleDelims(line, style); nl != e {
+			t.Fatalf("Expected %s style: %s; got: %s", style, e, nl)
+		}
+	}
+
+	if nl := dialects["smarty2"].restyleDelims(`call("{'{'}")`, "smarty"); nl != `call("{$smarty.ldelim}")` {
+		t.Fatalf("Expected smarty style within strings; got: %s", nl)
+	}
+
+	if nl := dialects["smarty2"].restyleDelims(`call("{'{'}")`, ""); nl != `call("{'{'}")` {
+		t.Fatalf("Expected string escape tags to be kept; got: %s", nl)
+	}
+
+	// a quote style tag never holds the quote of its string
+	line = `call('{ldelim}', "{rdelim}")`
+
+	if nl := dialects["smarty2"].restyleDelims(line, "quote"); nl != `call('{"{"}', "{'}'}")` {
+		t.Fatalf("Expected quote style within strings; got: %s", nl)
+	}
+}
+
+func TestStripEscapeTags(t *testing.T) {
+	line, count := dialects["smarty2"].stripEscapeTags(`{'{'}a: {$smarty.ldelim}{rdelim}{"}"} {$var}`)
+
+	if line != `a:  {$var}` || count != 4 {
+		t.Fatalf("Expected 4 escape tags stripped; got: %d %s", count, line)
+	}
+}
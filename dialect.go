@@ -0,0 +1,347 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// dialect holds the template engine rules a conversion must follow
+type dialect struct {
+	name string
+
+	// autoLiteral engines (Smarty 3 and later) treat a left brace followed
+	// by whitespace as plain text, so such braces need no escaping
+	autoLiteral bool
+
+	// escapes are every tag pair rendering a brace, the first one of each
+	// style is the one emitted
+	escapes []escapeTags
+
+	// literal are the tags opening and closing a block left unparsed
+	literal [2]string
+
+	// exts are the file extensions of its templates, those a tree run
+	// parses
+	exts []string
+
+	// tags are the names known to begin a tag when following a left brace,
+	// the engine functions and blocks along with the plugins given
+	tags map[string]bool
+}
+
+var smartyEscapes = []escapeTags{
+	{"delim", "{ldelim}", "{rdelim}"},
+	{"smarty", "{$smarty.ldelim}", "{$smarty.rdelim}"},
+	{"quote", "{'{'}", "{'}'}"},
+	{"quote", `{"{"}`, `{"}"}`},
+}
+
+var smartyLiteral = [2]string{"{literal}", "{/literal}"}
+
+var smartyExts = []string{".tpl"}
+
+// latteTags are the built-in Latte tags, {_"text"} translating its text
+var latteTags = map[string]bool{
+	"_": true, "block": true, "breakIf": true, "capture": true, "case": true,
+	"contentType": true, "continueIf": true, "control": true,
+	"debugbreak": true, "default": true, "define": true, "do": true,
+	"dump": true, "else": true, "elseif": true, "elseifset": true,
+	"embed": true, "extends": true, "first": true, "for": true,
+	"foreach": true, "form": true, "if": true, "ifchanged": true,
+	"ifcontent": true, "ifset": true, "import": true, "include": true,
+	"input": true, "iterateWhile": true, "label": true, "last": true,
+	"layout": true, "link": true, "parameters": true, "php": true,
+	"plink": true, "rollback": true, "sandbox": true, "sep": true,
+	"skipIf": true, "snippet": true, "snippetArea": true, "spaceless": true,
+	"switch": true, "templateType": true, "translate": true, "try": true,
+	"var": true, "varType": true, "while": true,
+}
+
+// canonical only knows {ldelim} and {rdelim}, the form every dialect tag
+// is turned into while parsing
+var canonical = dialect{
+	name:    "canonical",
+	escapes: smartyEscapes[:1],
+	tags:    smartyFunctions,
+}
+
+var dialects = map[string]dialect{
+	"smarty2": dialect{name: "smarty2", escapes: smartyEscapes, literal: smartyLiteral, exts: smartyExts, tags: smartyFunctions},
+	"smarty3": dialect{name: "smarty3", autoLiteral: true, escapes: smartyEscapes, literal: smartyLiteral, exts: smartyExts, tags: smartyFunctions},
+	"smarty4": dialect{name: "smarty4", autoLiteral: true, escapes: smartyEscapes, literal: smartyLiteral, exts: smartyExts, tags: smartyFunctions},
+	"latte": dialect{
+		name:        "latte",
+		autoLiteral: true,
+		escapes:     []escapeTags{{"delim", "{l}", "{r}"}},
+		literal:     [2]string{"{syntax off}", "{/syntax}"},
+		exts:        []string{".latte"},
+		tags:        latteTags,
+	},
+}
+
+func getDialect(name string) (dialect, error) {
+	d, ok := dialects[name]
+
+	if !ok {
+		return dialect{}, fmt.Errorf("Unknown dialect: %s", name)
+	}
+
+	return d, nil
+}
+
+// loadDialect reads a dialect definition from a JSON file, so engines other
+// than the built in ones can be converted, eg.
+//
+//	{
+//	  "name": "fenom",
+//	  "autoLiteral": true,
+//	  "escapes": [{"style": "quote", "left": "{'{'}", "right": "{'}'}"}],
+//	  "literal": ["{ignore}", "{/ignore}"],
+//	  "extensions": [".tpl"],
+//	  "tags": ["if", "foreach", "include", "set"]
+//	}
+//
+// The extensions default to .tpl and the tags to the Smarty ones
+func loadDialect(path string) (dialect, error) {
+	var def struct {
+		Name        string       `json:"name"`
+		AutoLiteral bool         `json:"autoLiteral"`
+		Escapes     []escapeTags `json:"escapes"`
+		Literal     [2]string    `json:"literal"`
+		Extensions  []string     `json:"extensions"`
+		Tags        []string     `json:"tags"`
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return dialect{}, err
+	}
+
+	if err = json.Unmarshal(b, &def); err != nil {
+		return dialect{}, fmt.Errorf("Invalid dialect definition %s: %s", path, err)
+	}
+
+	if def.Name == "" || len(def.Escapes) == 0 || def.Literal[0] == "" || def.Literal[1] == "" {
+		return dialect{}, fmt.Errorf("Dialect definition %s must have a name, escapes and literal tags", path)
+	}
+
+	for _, e := range def.Escapes {
+		if e.Style == "" || e.Left == "" || e.Right == "" {
+			return dialect{}, fmt.Errorf("Dialect definition %s has an incomplete escape", path)
+		}
+	}
+
+	d := dialect{
+		name:        def.Name,
+		autoLiteral: def.AutoLiteral,
+		escapes:     def.Escapes,
+		literal:     def.Literal,
+		exts:        def.Extensions,
+	}
+
+	if len(d.exts) == 0 {
+		d.exts = smartyExts
+	}
+
+	if len(def.Tags) == 0 {
+		d.tags = smartyFunctions
+	} else {
+		d.tags = map[string]bool{}
+
+		for _, name := range def.Tags {
+			d.tags[name] = true
+		}
+	}
+
+	return d, nil
+}
+
+// ------------ DETECTION
+
+// smartyClassPaths are the places a bundled Smarty.class.php is looked for
+var smartyClassPaths = []string{
+	"Smarty.class.php",
+	"libs/Smarty.class.php",
+	"smarty/libs/Smarty.class.php",
+	"vendor/smarty/smarty/libs/Smarty.class.php",
+}
+
+// detectDialect looks for the Smarty version used by the project owning dir,
+// walking up the tree until a composer.lock, composer.json or a bundled
+// Smarty.class.php tells which major version is installed. The walk stops
+// at the repository root owning dir, outside a repository only dir itself
+// is looked at
+func detectDialect(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	repo, ok := repositoryRoot(dir)
+	if !ok {
+		repo = dir
+	}
+
+	for {
+		if major, ok := composerLockVersion(filepath.Join(dir, "composer.lock")); ok {
+			return dialectForVersion(major)
+		}
+
+		if major, ok := composerJSONVersion(filepath.Join(dir, "composer.json")); ok {
+			return dialectForVersion(major)
+		}
+
+		for _, p := range smartyClassPaths {
+			if major, ok := smartyClassVersion(filepath.Join(dir, p)); ok {
+				return dialectForVersion(major)
+			}
+		}
+
+		if dir == repo {
+			return "", false
+		}
+
+		dir = filepath.Dir(dir)
+	}
+}
+
+func dialectForVersion(major int) (string, bool) {
+	switch {
+	case major == 2:
+		return "smarty2", true
+	case major == 3:
+		return "smarty3", true
+	case major >= 4:
+		return "smarty4", true
+	}
+
+	return "", false
+}
+
+func composerLockVersion(path string) (int, bool) {
+	var lock struct {
+		Packages    []struct{ Name, Version string } `json:"packages"`
+		PackagesDev []struct{ Name, Version string } `json:"packages-dev"`
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil || json.Unmarshal(b, &lock) != nil {
+		return 0, false
+	}
+
+	for _, p := range append(lock.Packages, lock.PackagesDev...) {
+		if p.Name == "smarty/smarty" {
+			return majorVersion(p.Version)
+		}
+	}
+
+	return 0, false
+}
+
+func composerJSONVersion(path string) (int, bool) {
+	var composer struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil || json.Unmarshal(b, &composer) != nil {
+		return 0, false
+	}
+
+	if v, ok := composer.Require["smarty/smarty"]; ok {
+		return majorVersion(v)
+	}
+
+	if v, ok := composer.RequireDev["smarty/smarty"]; ok {
+		return majorVersion(v)
+	}
+
+	return 0, false
+}
+
+func smartyClassVersion(path string) (int, bool) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	re := `(SMARTY_VERSION|\$_version)\s*=\s*['"]([^'"]+)['"]`
+	matches := regexp.MustCompile(re).FindSubmatch(b)
+
+	if matches == nil {
+		return 0, false
+	}
+
+	return majorVersion(string(matches[2]))
+}
+
+// majorVersion reads the first number of a version or composer constraint
+// such as v3.1.39, Smarty-3.0.8, ^4.0 or ~2.6
+func majorVersion(version string) (int, bool) {
+	match := regexp.MustCompile(`\d+`).FindString(version)
+
+	if match == "" {
+		return 0, false
+	}
+
+	major, err := strconv.Atoi(match)
+
+	return major, err == nil
+}
+
+// ------------ AUTO LITERAL
+
+// autoLiteralDelims keeps only the {ldelim} an auto_literal engine would
+// otherwise parse as a tag, those followed by whitespace become bare braces
+// along with their {rdelim} pair, any {rdelim} left unpaired on the line
+// is made bare as a lone right brace is always plain text. Quoted strings
+// are left untouched
+func autoLiteralDelims(line string) string {
+	var kept []bool
+
+	pop := func() bool {
+		if len(kept) == 0 {
+			return false
+		}
+
+		keep := kept[len(kept)-1]
+		kept = kept[:len(kept)-1]
+
+		return keep
+	}
+
+	return canonical.mapDelims(line, func(token delimToken, text, rest string) string {
+		switch token {
+		case leftDelim:
+			keep := rest == "" || !isSpace(rest[0])
+			kept = append(kept, keep)
+
+			if !keep {
+				return "{"
+			}
+		case rightDelim:
+			if !pop() {
+				return "}"
+			}
+		case leftBareBrace:
+			kept = append(kept, false)
+		case rightBareBrace:
+			pop()
+		}
+
+		return text
+	})
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
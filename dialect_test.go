@@ -0,0 +1,255 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetDialect(t *testing.T) {
+	for _, name := range []string{"smarty2", "smarty3", "smarty4"} {
+		d, err := getDialect(name)
+
+		if err != nil {
+			t.Fatalf("Expected dialect %s; got error: %s", name, err)
+		}
+
+		if d.name != name {
+			t.Fatalf("Expected dialect name: %s; got: %s", name, d.name)
+		}
+	}
+
+	if _, err := getDialect("smarty1"); err == nil {
+		t.Fatal("Expected error on unknown dialect")
+	}
+}
+
+// ------------ AUTO LITERAL
+
+var autoLiteralLines = []string{
+	"function () {ldelim}\n",
+	"call({ldelim}\n",
+	"funcion () {ldelim}// comment",
+	"{rdelim}, {ldelim}\n",
+	"{rdelim})\n",
+	`const myObject = {ldelim}hello: "world", myObject:{ldelim} one: 1 {rdelim}{rdelim}`,
+	`const single = {ldelim}{rdelim}`,
+	`if (a) {ldelim} return {ldelim}a: 1{rdelim} {rdelim}`,
+	`let myVar = {json_decode($jsonVariable)} {rdelim}`,
+	`let a = {ldelim}{ldelim} b {rdelim}{rdelim}`,
+}
+
+var expAutoLiteralLines = []string{
+	"function () {\n",
+	"call({\n",
+	"funcion () {ldelim}// comment",
+	"}, {\n",
+	"})\n",
+	`const myObject = {ldelim}hello: "world", myObject:{ one: 1 }{rdelim}`,
+	`const single = {ldelim}{rdelim}`,
+	`if (a) { return {ldelim}a: 1{rdelim} }`,
+	`let myVar = {json_decode($jsonVariable)} }`,
+	`let a = {ldelim}{ b }{rdelim}`,
+}
+
+var nonAutoLiteralLines = []string{
+	`<script type="text/javascript">`,
+	`let myVar = {json_decode($jsonVariable)}`,
+	`let myOtherVar = '{$wuuuu}'`,
+	`console.log({include file=$myCustomFile})`,
+	`{ldelim}a: 1{rdelim}`,
+	`{ldelim}/literal{rdelim}`,
+	`{ldelim}$var{rdelim}`,
+	`function () {ldelim}`,
+	`</script>`,
+}
+
+func TestAutoLiteralDelims(t *testing.T) {
+	for i, line := range autoLiteralLines {
+		nl := autoLiteralDelims(line)
+
+		if nl != expAutoLiteralLines[i] {
+			t.Fatalf("Expected auto literal line: %s; got: %s", expAutoLiteralLines[i], nl)
+		}
+	}
+}
+
+func TestAutoLiteralDelimsNoChange(t *testing.T) {
+	for _, line := range nonAutoLiteralLines {
+		if nl := autoLiteralDelims(line); nl != line {
+			t.Fatalf("Should not change %s; got: %s", line, nl)
+		}
+	}
+}
+
+// ------------ DETECTION
+
+var detectDialectDirs = []string{
+	"files/detect/lock",
+	"files/detect/json",
+	"files/detect/bundled",
+}
+
+var expDetectDialect = []string{
+	"smarty4",
+	"smarty3",
+	"smarty2",
+}
+
+func TestDetectDialect(t *testing.T) {
+	for i, dir := range detectDialectDirs {
+		name, ok := detectDialect(dir)
+
+		if !ok {
+			t.Fatalf("Should detect dialect in %s", dir)
+		}
+
+		if name != expDetectDialect[i] {
+			t.Fatalf("Expected dialect: %s; got: %s", expDetectDialect[i], name)
+		}
+	}
+}
+
+func TestDetectDialectBoundary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smarty-brace-delim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the composer.json of dir is above both the repository and the plain
+	// directory, the composer.lock of app belongs to the repository
+	files := map[string]string{
+		"composer.json":          "files/detect/json/composer.json",
+		"repo/app/composer.lock": "files/detect/lock/composer.lock",
+	}
+
+	for name, fixture := range files {
+		content, err := ioutil.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"repo/.git", "repo/templates", "repo/app/templates", "plain/templates"} {
+		os.MkdirAll(filepath.Join(dir, name), 0755)
+	}
+
+	if name, ok := detectDialect(filepath.Join(dir, "repo/app/templates")); !ok || name != "smarty4" {
+		t.Fatalf("Expected dialect found within the repository: smarty4; got: %s", name)
+	}
+
+	if name, ok := detectDialect(filepath.Join(dir, "repo/templates")); ok {
+		t.Fatalf("Should stop at the repository root; got: %s", name)
+	}
+
+	if name, ok := detectDialect(filepath.Join(dir, "plain/templates")); ok {
+		t.Fatalf("Should only look at the directory outside a repository; got: %s", name)
+	}
+}
+
+var majorVersions = []string{
+	"v3.1.39",
+	"Smarty-3.0.8",
+	"^4.0",
+	"~2.6",
+	">=3.1 <5",
+	"2.6.31",
+}
+
+var expMajorVersions = []int{3, 3, 4, 2, 3, 2}
+
+func TestMajorVersion(t *testing.T) {
+	for i, v := range majorVersions {
+		major, ok := majorVersion(v)
+
+		if !ok || major != expMajorVersions[i] {
+			t.Fatalf("Expected major version of %s: %d; got: %d", v, expMajorVersions[i], major)
+		}
+	}
+
+	if _, ok := majorVersion("dev-master"); ok {
+		t.Fatal("Should not find a major version in dev-master")
+	}
+}
+
+// ------------ DEFINITIONS
+
+func TestLoadDialect(t *testing.T) {
+	d, err := loadDialect("files/dialects/fenom.json")
+	if err != nil {
+		t.Fatalf("Expected error to be nil; got: %s", err)
+	}
+
+	if _, err = getDialect("fenom"); err == nil {
+		t.Fatal("Should not register the loaded dialect")
+	}
+
+	if !d.autoLiteral || d.literal[0] != "{ignore}" || d.literal[1] != "{/ignore}" {
+		t.Fatalf("Unexpected dialect definition: %+v", d)
+	}
+
+	if !d.isSmartyTagStart(`{set $a = 1}`) || d.isSmartyTagStart(`{assign var=a}`) {
+		t.Fatal("Expected the tags of the definition only")
+	}
+
+	if len(d.exts) != 1 || d.exts[0] != ".tpl" {
+		t.Fatalf("Expected default extensions: [.tpl]; got: %v", d.exts)
+	}
+
+	if !d.startOfLiteralTag("  {ignore}\n") || !d.endOfLiteralTag("{/ignore} // end\n") {
+		t.Fatal("Expected ignore tags to open and close a literal block")
+	}
+
+	if d.startOfLiteralTag("{literal}") {
+		t.Fatal("Should not be literal tag {literal}")
+	}
+
+	line := d.canonicalDelims(`call({'{'}a: "{'{'}"{'}'})`)
+	exp := `call({ldelim}a: "{'{'}"{rdelim})`
+
+	if line != exp {
+		t.Fatalf("Expected canonical delims: %s; got: %s", exp, line)
+	}
+
+	if line = d.restyleDelims(exp, ""); line != `call({'{'}a: "{'{'}"{'}'})` {
+		t.Fatalf("Expected quote style delims; got: %s", line)
+	}
+}
+
+func TestLoadDialectInvalid(t *testing.T) {
+	if _, err := loadDialect("files/dialects/invalid.json"); err == nil {
+		t.Fatal("Expected error on incomplete dialect definition")
+	}
+
+	if _, err := loadDialect("files/dialects/none.json"); err == nil {
+		t.Fatal("Expected error on missing dialect definition")
+	}
+}
+
+func TestLatteDialect(t *testing.T) {
+	d := dialects["latte"]
+
+	if line := d.canonicalDelims(`{l}a: 1{r}`); line != `{ldelim}a: 1{rdelim}` {
+		t.Fatalf("Expected latte tags to be recognised; got: %s", line)
+	}
+
+	if line := d.restyleDelims(`{ldelim}a: 1{rdelim}`, "delim"); line != `{l}a: 1{r}` {
+		t.Fatalf("Expected latte tags to be emitted; got: %s", line)
+	}
+
+	if _, ok := d.escapeStyle("smarty"); ok {
+		t.Fatal("Latte should not have smarty escape style")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ------------ DIFF
+
+// diffContext is the number of unchanged lines around each hunk
+const diffContext = 3
+
+// diffOp is a line kept (' '), removed ('-') or added ('+')
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the unified diff turning a into b, empty when they
+// are the same
+func unifiedDiff(aName, bName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	ops := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	fmt.Fprintf(&buf, "diff %s %s\n--- %s\n+++ %s\n", aName, bName, aName, bName)
+
+	for start := 0; start < len(ops); {
+		first := nextChange(ops, start)
+		if first < 0 {
+			break
+		}
+
+		// a hunk goes on while changes are close enough to share context
+		last := first
+		for next := nextChange(ops, last+1); next >= 0 && next-last <= 2*diffContext+1; next = nextChange(ops, last+1) {
+			last = next
+		}
+
+		from, to := first-diffContext, last+diffContext+1
+		if from < start {
+			from = start
+		}
+
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		writeHunk(&buf, ops, from, to)
+		start = to
+	}
+
+	return buf.String()
+}
+
+// nextChange returns the index of the first removed or added line from i
+func nextChange(ops []diffOp, i int) int {
+	for ; i < len(ops); i++ {
+		if ops[i].kind != ' ' {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func writeHunk(buf *bytes.Buffer, ops []diffOp, from, to int) {
+	var aLine, bLine, aCount, bCount int
+
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			aLine++
+		}
+
+		if op.kind != '-' {
+			bLine++
+		}
+	}
+
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			aCount++
+		}
+
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+
+	// an empty range starts at the line before it
+	if aCount > 0 {
+		aLine++
+	}
+
+	if bCount > 0 {
+		bLine++
+	}
+
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+
+	for _, op := range ops[from:to] {
+		buf.WriteByte(op.kind)
+		buf.WriteString(op.line)
+
+		if !strings.HasSuffix(op.line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// diffLines returns the operations turning the lines of a into those of b
+// following their longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+
+	return ops
+}
+
+// splitLines splits s after each line break
+func splitLines(s string) []string {
+	var lines []string
+
+	for s != "" {
+		end := strings.Index(s, "\n") + 1
+		if end == 0 {
+			end = len(s)
+		}
+
+		lines = append(lines, s[:end])
+		s = s[end:]
+	}
+
+	return lines
+}
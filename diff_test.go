@@ -0,0 +1,53 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n15\n16\nseventeen"
+
+	exp := `diff a.orig a
+--- a.orig
++++ a
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -11,6 +11,6 @@
+ 11
+ 12
+ 13
+-14
+ 15
+ 16
++seventeen
+\ No newline at end of file
+`
+
+	if d := unifiedDiff("a.orig", "a", []byte(a), []byte(b)); d != exp {
+		t.Fatalf("Expected diff:\n%s\ngot:\n%s", exp, d)
+	}
+
+	if d := unifiedDiff("a.orig", "a", []byte(a), []byte(a)); d != "" {
+		t.Fatalf("Expected no diff; got:\n%s", d)
+	}
+}
+
+func TestUnifiedDiffMergedHunk(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	b := "A\nb\nc\nd\ne\nf\ng\nH\n"
+
+	exp := "diff x.orig x\n--- x.orig\n+++ x\n@@ -1,8 +1,8 @@\n-a\n+A\n b\n c\n d\n e\n f\n g\n-h\n+H\n"
+
+	if d := unifiedDiff("x.orig", "x", []byte(a), []byte(b)); d != exp {
+		t.Fatalf("Expected diff:\n%s\ngot:\n%s", exp, d)
+	}
+}
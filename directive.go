@@ -0,0 +1,74 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "regexp"
+
+// ------------ DIRECTIVES
+
+// directive is a comment telling both parses to leave lines alone where the
+// heuristics would get them wrong
+type directive int
+
+const (
+	noDirective directive = iota
+
+	// offDirective, {* smarty-brace: off *}, leaves its line and the
+	// following ones untouched
+	offDirective
+
+	// onDirective, {* smarty-brace: on *}, ends an off region, its own line
+	// still untouched
+	onDirective
+
+	// ignoreNextLineDirective, // smarty-brace-ignore-next-line, leaves the
+	// line after it untouched
+	ignoreNextLineDirective
+)
+
+var directiveRe = regexp.MustCompile(`\{\*\s*smarty-brace:\s*(off|on)\s*\*\}|//\s*smarty-brace-ignore-next-line\b`)
+
+func parseDirective(line string) directive {
+	match := directiveRe.FindStringSubmatch(line)
+
+	switch {
+	case match == nil:
+		return noDirective
+	case match[1] == "off":
+		return offDirective
+	case match[1] == "on":
+		return onDirective
+	}
+
+	return ignoreNextLineDirective
+}
+
+// directiveState follows the directives met line by line
+type directiveState struct {
+	off      bool
+	nextLine bool
+}
+
+// ignores tells whether line is to be left untouched, updating the state
+// with the directive it holds
+func (ds *directiveState) ignores(line string) bool {
+	d := parseDirective(line)
+	ignored := ds.off || ds.nextLine || d == offDirective || d == onDirective
+
+	ds.nextLine = d == ignoreNextLineDirective
+	ds.off = (ds.off || d == offDirective) && d != onDirective
+
+	return ignored
+}
+
+// scriptStateAfter tells whether a script is open after line, which is left
+// untouched, inside telling whether one was before it
+func scriptStateAfter(line string, inside bool) bool {
+	if inside {
+		return !endOfScriptTag(line)
+	}
+
+	return startOfScriptTag(line)
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestParseDirective(t *testing.T) {
+	lines := []string{
+		`{* smarty-brace: off *}`,
+		`  {*smarty-brace:on*}`,
+		`var a = 1; // smarty-brace-ignore-next-line`,
+		`{* smarty-brace: maybe *}`,
+		`// smarty-brace-ignore-next-lines`,
+		`var a = {b: 1};`,
+	}
+
+	expected := []directive{offDirective, onDirective, ignoreNextLineDirective, noDirective, noDirective, noDirective}
+
+	for i, line := range lines {
+		if d := parseDirective(line); d != expected[i] {
+			t.Fatalf("Expected directive %d for %s; got: %d", expected[i], line, d)
+		}
+	}
+}
+
+func TestDirectiveState(t *testing.T) {
+	lines := []string{
+		`var a = {b: 1};`,
+		`// smarty-brace-ignore-next-line`,
+		`var c = {d: 2};`,
+		`var e = {f: 3};`,
+		`{* smarty-brace: off *}`,
+		`var g = {h: 4};`,
+		`{* smarty-brace: on *}`,
+		`var i = {j: 5};`,
+	}
+
+	expected := []bool{false, false, true, false, true, true, true, false}
+
+	var ds directiveState
+
+	for i, line := range lines {
+		if ignored := ds.ignores(line); ignored != expected[i] {
+			t.Fatalf("Expected ignored %t for line %d; got: %t", expected[i], i+1, ignored)
+		}
+	}
+}
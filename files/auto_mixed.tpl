@@ -0,0 +1,6 @@
+<script type="text/javascript">
+var config = {ldelim}theme: "{$theme}"{rdelim};
+function init() {
+  return {ready: true};
+}
+</script>
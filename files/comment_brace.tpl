@@ -0,0 +1,11 @@
+<script type="text/javascript">
+var url = "http://example.com/{$path}", cfg = {a: 1}
+var glob = "/*", o = {b: 2} // {c: 3}
+var c = {d: 3} /* {e: 4} */
+{literal}
+/**
+ * {* kept as is *} {f: 5}
+ */
+var g = {h: 6}
+{/literal}
+</script>
@@ -0,0 +1,11 @@
+<script type="text/javascript">
+var url = "http://example.com/{$path}", cfg = {ldelim}a: 1{rdelim}
+var glob = "/*", o = {ldelim}b: 2{rdelim} // {c: 3}
+var c = {ldelim}d: 3{rdelim} /* {e: 4} */
+{literal}
+/**
+ * {* kept as is *} {f: 5}
+ */
+var g = {h: 6}
+{/literal}
+</script>
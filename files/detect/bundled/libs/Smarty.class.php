@@ -0,0 +1,16 @@
+<?php
+/**
+ * Project:     Smarty: the PHP compiling template engine
+ */
+class Smarty
+{
+    /**#@+
+     * Smarty Configuration Section
+     */
+    var $template_dir    =  'templates';
+
+    /**
+     * The version number
+     */
+    var $_version              = '2.6.31';
+}
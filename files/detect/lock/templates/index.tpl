@@ -0,0 +1,4 @@
+<script type="text/javascript">
+function () {
+}
+</script>
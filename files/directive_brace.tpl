@@ -0,0 +1,11 @@
+<script type="text/javascript">
+var a = {b: 1};
+// smarty-brace-ignore-next-line
+var c = '{$d}' + {e: 2};
+var f = {g: 3};
+{* smarty-brace: off *}
+var h = {i: 4}, j = {ldelim}k{rdelim};
+function l() { return {m: 5}; }
+{* smarty-brace: on *}
+var n = {o: 6};
+</script>
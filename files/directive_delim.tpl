@@ -0,0 +1,11 @@
+<script type="text/javascript">
+var a = {ldelim}b: 1{rdelim};
+// smarty-brace-ignore-next-line
+var c = '{$d}' + {e: 2};
+var f = {ldelim}g: 3{rdelim};
+{* smarty-brace: off *}
+var h = {i: 4}, j = {ldelim}k{rdelim};
+function l() { return {m: 5}; }
+{* smarty-brace: on *}
+var n = {ldelim}o: 6{rdelim};
+</script>
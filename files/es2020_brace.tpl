@@ -0,0 +1,37 @@
+<script type="module">
+import {render, html} from './lib.js'
+export {render}
+const {a, b: {c}, ...rest} = {$config|json_encode}
+const [first, {id}] = items
+const add = (x, y) => {
+  return x + y
+}
+const make = () => ({x: 1, y: 2})
+const merged = {...defaults, ...{$overrides|json_encode}, shorthand, [`key_${id}`]: true, ['k' + n]: 1}
+class Store extends Base {
+  #items = {}
+  static defaults = {size: 10}
+  constructor({size} = {}) { super(); this.size = size }
+  get count() { return Object.keys(this.#items).length }
+  async load(url) {
+    try { const res = await fetch(url); return res?.json?.() ?? {} } catch {} finally {}
+  }
+  *[Symbol.iterator]() { yield* Object.values(this.#items) }
+}
+const name = user?.profile?.name ?? '{$defaultName}'
+for (const {key, value} of entries) { console.log(`${key}: ${value}`) }
+if (x) {} else if (y) { z() }
+label: { break label }
+const fn = async ({signal}) => { for await (const chunk of stream) {} }
+const big = 10n ** 20n, re = /\{\d+\}/u
+const o = {get x() {return 1}, set x(v) {}, m() {}, async *gen() {}, [k]: v, 'q': {}, "w": 2}
+const f = x => /a{2}/.test(x), g = x => ({...x})
+const h = a?.[0]?.b ?? {}
+obj.filter(({done}) => !done).map(({id, ...r}) => ({id, r}))
+const t = tag`a ${b ? `{c}` : {d: 1}} e`
+new Promise((resolve) => { setTimeout(() => { resolve({ok: true}) }, 10) })
+const {data: {items = []} = {}} = await import('./x.js')
+switch (k) { case 'a': { break } default: {} }
+const n = 1_000_000, m = x ** 2 / 4, z = {$a|default:0} / {$b}
+class A {#count = 0; inc() {this.#count++}}
+</script>
@@ -0,0 +1,37 @@
+<script type="module">
+import {ldelim}render, html{rdelim} from './lib.js'
+export {ldelim}render{rdelim}
+const {ldelim}a, b: {ldelim}c{rdelim}, ...rest{rdelim} = {$config|json_encode}
+const [first, {ldelim}id{rdelim}] = items
+const add = (x, y) => {ldelim}
+  return x + y
+{rdelim}
+const make = () => ({ldelim}x: 1, y: 2{rdelim})
+const merged = {ldelim}...defaults, ...{$overrides|json_encode}, shorthand, [`key_${ldelim}id{rdelim}`]: true, ['k' + n]: 1{rdelim}
+class Store extends Base {ldelim}
+  #items = {ldelim}{rdelim}
+  static defaults = {ldelim}size: 10{rdelim}
+  constructor({ldelim}size{rdelim} = {ldelim}{rdelim}) {ldelim} super(); this.size = size {rdelim}
+  get count() {ldelim} return Object.keys(this.#items).length {rdelim}
+  async load(url) {ldelim}
+    try {ldelim} const res = await fetch(url); return res?.json?.() ?? {ldelim}{rdelim} {rdelim} catch {ldelim}{rdelim} finally {ldelim}{rdelim}
+  {rdelim}
+  *[Symbol.iterator]() {ldelim} yield* Object.values(this.#items) {rdelim}
+{rdelim}
+const name = user?.profile?.name ?? '{$defaultName}'
+for (const {ldelim}key, value{rdelim} of entries) {ldelim} console.log(`${ldelim}key{rdelim}: ${ldelim}value{rdelim}`) {rdelim}
+if (x) {ldelim}{rdelim} else if (y) {ldelim} z() {rdelim}
+label: {ldelim} break label {rdelim}
+const fn = async ({ldelim}signal{rdelim}) => {ldelim} for await (const chunk of stream) {ldelim}{rdelim} {rdelim}
+const big = 10n ** 20n, re = /\{\d+\}/u
+const o = {ldelim}get x() {ldelim}return 1{rdelim}, set x(v) {ldelim}{rdelim}, m() {ldelim}{rdelim}, async *gen() {ldelim}{rdelim}, [k]: v, 'q': {ldelim}{rdelim}, "w": 2{rdelim}
+const f = x => /a{2}/.test(x), g = x => ({ldelim}...x{rdelim})
+const h = a?.[0]?.b ?? {ldelim}{rdelim}
+obj.filter(({ldelim}done{rdelim}) => !done).map(({ldelim}id, ...r{rdelim}) => ({ldelim}id, r{rdelim}))
+const t = tag`a ${ldelim}b ? `{c}` : {ldelim}d: 1{rdelim}{rdelim} e`
+new Promise((resolve) => {ldelim} setTimeout(() => {ldelim} resolve({ldelim}ok: true{rdelim}) {rdelim}, 10) {rdelim})
+const {ldelim}data: {ldelim}items = []{rdelim} = {ldelim}{rdelim}{rdelim} = await import('./x.js')
+switch (k) {ldelim} case 'a': {ldelim} break {rdelim} default: {ldelim}{rdelim} {rdelim}
+const n = 1_000_000, m = x ** 2 / 4, z = {$a|default:0} / {$b}
+class A {ldelim}#count = 0; inc() {ldelim}this.#count++{rdelim}{rdelim}
+</script>
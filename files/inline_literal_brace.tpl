@@ -0,0 +1,6 @@
+<script type="text/javascript">
+var x = {literal}{a: 1}{/literal}; var conv = {};
+var y = {b: {$c}}, z = {literal}{d: {e: 2}}{/literal}, w = {f: 3};
+function g() { return {literal}{h: 4}{/literal}; }
+var i = {j: 5};
+</script>
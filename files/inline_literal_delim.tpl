@@ -0,0 +1,6 @@
+<script type="text/javascript">
+var x = {literal}{a: 1}{/literal}; var conv = {ldelim}{rdelim};
+var y = {ldelim}b: {$c}{rdelim}, z = {literal}{d: {e: 2}}{/literal}, w = {ldelim}f: 3{rdelim};
+function g() {ldelim} return {literal}{h: 4}{/literal}; {rdelim}
+var i = {ldelim}j: 5{rdelim};
+</script>
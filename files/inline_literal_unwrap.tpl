@@ -0,0 +1,6 @@
+<script type="text/javascript">
+var x = {a: 1}; var conv = {};
+var y = {b: {$c}}, z = {d: {e: 2}}, w = {f: 3};
+function g() { return {h: 4}; }
+var i = {j: 5};
+</script>
@@ -0,0 +1,27 @@
+<!-- <script src="js/old.js"></script> -->
+<!--
+<script type="text/javascript">
+var disabled = {a: 1};
+</script>
+-->
+<script type="text/javascript">
+<!--
+function a() { return {b: 1}; }
+// old: {c: 2}
+//-->
+</script>
+<script type="text/javascript">
+//<![CDATA[
+var c = {d: 2};
+//]]>
+</script>
+<script type="text/javascript">
+/* <![CDATA[ */
+var e = {f: 3};
+/* ]]> */
+</script>
+<script type="text/javascript"><!--
+var g = {h: 4};
+// --></script>
+<style>body {margin: 0}</style>
+<p>{$after}</p>
@@ -0,0 +1,27 @@
+<!-- <script src="js/old.js"></script> -->
+<!--
+<script type="text/javascript">
+var disabled = {a: 1};
+</script>
+-->
+<script type="text/javascript">
+<!--
+function a() {ldelim} return {ldelim}b: 1{rdelim}; {rdelim}
+// old: {c: 2}
+//-->
+</script>
+<script type="text/javascript">
+//<![CDATA[
+var c = {ldelim}d: 2{rdelim};
+//]]>
+</script>
+<script type="text/javascript">
+/* <![CDATA[ */
+var e = {ldelim}f: 3{rdelim};
+/* ]]> */
+</script>
+<script type="text/javascript"><!--
+var g = {ldelim}h: 4{rdelim};
+// --></script>
+<style>body {margin: 0}</style>
+<p>{$after}</p>
@@ -0,0 +1,7 @@
+<script type="text/javascript">
+{literal}var a = {b: 1};
+var c = { d: 2 };
+var i = {j: 3};{/literal}
+{literal}var e = { f: 1 };
+var g = { h: 2 };{/literal}
+</script>
@@ -0,0 +1,16 @@
+<script type="text/javascript">
+call({ldelim}
+  hello: "{$name}"
+{$smarty.rdelim}, {'{'}
+  world: "hello"
+{rdelim})
+{literal}
+let array = [{
+  hello: "world"
+}]
+{/literal}
+function () {
+  console.log("{ldelim}", "{'{'}")
+}
+{literal}var tpl = "<b>{$price}</b>"; if (a) {if (b) {c()}}{/literal}
+</script>
@@ -0,0 +1,14 @@
+<script type="text/javascript">
+call({ldelim}
+  hello: "{$name}"
+{rdelim}, {ldelim}
+  world: "hello"
+{rdelim})
+let array = [{ldelim}
+  hello: "world"
+{rdelim}]
+function () {ldelim}
+  console.log("{ldelim}", "{ldelim}")
+{rdelim}
+{literal}var tpl = "<b>{$price}</b>"; if (a) {if (b) {c()}}{/literal}
+</script>
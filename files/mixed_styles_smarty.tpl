@@ -0,0 +1,14 @@
+<script type="text/javascript">
+call({$smarty.ldelim}
+  hello: "{$name}"
+{$smarty.rdelim}, {$smarty.ldelim}
+  world: "hello"
+{$smarty.rdelim})
+let array = [{$smarty.ldelim}
+  hello: "world"
+{$smarty.rdelim}]
+function () {$smarty.ldelim}
+  console.log("{$smarty.ldelim}", "{$smarty.ldelim}")
+{$smarty.rdelim}
+{literal}var tpl = "<b>{$price}</b>"; if (a) {if (b) {c()}}{/literal}
+</script>
@@ -0,0 +1,6 @@
+<script type="text/javascript">
+var page = {title: "{$title|truncate:30:"..."|escape:'javascript'}", year: {$date|date_format:"%Y"}}
+var meta = {$meta|@json_encode}, name = '{#siteName#}', opts = {limit: {#pageSize#}}
+var label = {$label|default:'{none}'|escape}, tip = {$tip|replace:'}':')'}
+if ({$widgets|@count|string_format:"%d"} > 0) {init({count: {$widgets|@count}})}
+</script>
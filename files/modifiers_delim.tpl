@@ -0,0 +1,6 @@
+<script type="text/javascript">
+var page = {ldelim}title: "{$title|truncate:30:"..."|escape:'javascript'}", year: {$date|date_format:"%Y"}{rdelim}
+var meta = {$meta|@json_encode}, name = '{#siteName#}', opts = {ldelim}limit: {#pageSize#}{rdelim}
+var label = {$label|default:'{none}'|escape}, tip = {$tip|replace:'}':')'}
+if ({$widgets|@count|string_format:"%d"} > 0) {ldelim}init({ldelim}count: {$widgets|@count}{rdelim}){rdelim}
+</script>
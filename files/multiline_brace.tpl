@@ -0,0 +1,9 @@
+<script type="text/javascript">
+var a = {b: 1};
+var s = `first {$name}
+  multi ${ {a:1}.a } line`;
+var c = {d: 2};
+var t = "one {$name} \
+  {e: 3} two";
+var f = {g: 4};
+</script>
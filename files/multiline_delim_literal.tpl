@@ -0,0 +1,15 @@
+<script type="text/javascript">
+{literal}
+var a = {b: 1};
+{/literal}
+var s = `first {$name}
+  multi ${ldelim} {ldelim}a:1{rdelim}.a {rdelim} line`;
+{literal}
+var c = {d: 2};
+{/literal}
+var t = "one {$name} \
+  {ldelim}e: 3{rdelim} two";
+{literal}
+var f = {g: 4};
+{/literal}
+</script>
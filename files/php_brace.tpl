@@ -0,0 +1,22 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<?php
+$config = array('theme' => 'dark');
+if ($a) { echo '{ready}'; }
+?>
+<script type="text/javascript">
+var config = <?= json_encode($config) ?>, opts = {a: 1};
+var b = {php}echo '{b}';{/php};
+{php}
+  $c = array();
+  function d() { return '{}'; }
+{/php}
+function e() { return {c: <?php echo $c ?>}; }
+var f = {g: 1}; <?php
+  $h = array('i' => '{j}');
+?>
+var k = <?php
+  $y = 1; ?> {x: 1};
+<?php
+  $z = 2;
+?></script>
+<div>{foo}</div>
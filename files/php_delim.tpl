@@ -0,0 +1,22 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<?php
+$config = array('theme' => 'dark');
+if ($a) { echo '{ready}'; }
+?>
+<script type="text/javascript">
+var config = <?= json_encode($config) ?>, opts = {ldelim}a: 1{rdelim};
+var b = {php}echo '{b}';{/php};
+{php}
+  $c = array();
+  function d() { return '{}'; }
+{/php}
+function e() {ldelim} return {ldelim}c: <?php echo $c ?>{rdelim}; {rdelim}
+var f = {ldelim}g: 1{rdelim}; <?php
+  $h = array('i' => '{j}');
+?>
+var k = <?php
+  $y = 1; ?> {ldelim}x: 1{rdelim};
+<?php
+  $z = 2;
+?></script>
+<div>{foo}</div>
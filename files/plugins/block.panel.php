@@ -0,0 +1,5 @@
+<?php
+
+function smarty_block_panel($params, $smarty)
+{
+}
@@ -0,0 +1,5 @@
+<?php
+
+function smarty_function_widget($params, $smarty)
+{
+}
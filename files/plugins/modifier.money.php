@@ -0,0 +1,5 @@
+<?php
+
+function smarty_modifier_money($params, $smarty)
+{
+}
@@ -0,0 +1,7 @@
+<script type="text/javascript">
+var s = "{" + x
+var j = "{\"a\": {$b}}"
+var o = {a: `{b}`}
+// {c}
+/* {d} */ var e = {f: /x{2}/}
+</script>
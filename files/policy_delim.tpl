@@ -0,0 +1,7 @@
+<script type="text/javascript">
+var s = "{ldelim}" + x
+var j = "{ldelim}\"a\": {$b}{rdelim}"
+var o = {ldelim}a: `{ldelim}b{rdelim}`{rdelim}
+// {ldelim}c{rdelim}
+/* {ldelim}d{rdelim} */ var e = {ldelim}f: /x{ldelim}2{rdelim}/{rdelim}
+</script>
@@ -0,0 +1,8 @@
+<script type="text/javascript">
+var messages = {
+  title: 'It\'s {$title|escape:'javascript'} {ldelim}draft{rdelim}',
+  body: "Say \"{ldelim}hi{rdelim}\" to {$user.name}",
+  path: 'C:\\templates\\' + {dir: '{$dir}'}.dir
+}
+if (a === 'don\'t') { alert("{ldelim}\"}") }
+</script>
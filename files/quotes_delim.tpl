@@ -0,0 +1,8 @@
+<script type="text/javascript">
+var messages = {ldelim}
+  title: 'It\'s {$title|escape:'javascript'} {ldelim}draft{rdelim}',
+  body: "Say \"{ldelim}hi{rdelim}\" to {$user.name}",
+  path: 'C:\\templates\\' + {ldelim}dir: '{$dir}'{rdelim}.dir
+{rdelim}
+if (a === 'don\'t') {ldelim} alert("{ldelim}\"}") {rdelim}
+</script>
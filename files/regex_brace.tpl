@@ -0,0 +1,8 @@
+<script type="text/javascript">
+function valid(date) {
+  return /^\d{4}-\d{2}-\d{2}$/.test(date) && !/[{}]/.test(date)
+}
+let ratio = {$total} / 2 / {$pages}
+let rules = {zip: /^\d{5}$/, phone: /^[0-9]{7,10}$/i}
+let url = "http://example.com/{$path}/"
+</script>
@@ -0,0 +1,8 @@
+<script type="text/javascript">
+function valid(date) {ldelim}
+  return /^\d{4}-\d{2}-\d{2}$/.test(date) && !/[{}]/.test(date)
+{rdelim}
+let ratio = {$total} / 2 / {$pages}
+let rules = {ldelim}zip: /^\d{5}$/, phone: /^[0-9]{7,10}$/i{rdelim}
+let url = "http://example.com/{$path}/"
+</script>
@@ -30,7 +30,7 @@ echo "{ldelim}", "{rdelim}"
 console.log('{rdelim}')
 console.log("{ldelim}")
 object.call('{rdelim}', "{ldelim}", `{ldelim} & {rdelim}`)
-object = {ldelim}left: ["{lrdelim}", "{rdelim}"], right: {ldelim}"{rdelim}", "{ldelim}"{rdelim}{rdelim}
+object = {ldelim}left: ["{ldelim}lrdelim{rdelim}", "{rdelim}"], right: {ldelim}"{rdelim}", "{ldelim}"{rdelim}{rdelim}
 
 // this is not actually a {literal}
 funcion () {ldelim}// this have ldelim: {ldelim} ?
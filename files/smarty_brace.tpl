@@ -0,0 +1,11 @@
+<script type="text/javascript">
+var cfg = {
+  {include file="cfg.tpl"
+    assign=cfg}
+  user: {$user|json_encode},
+  {if $admin
+    && $debug}debug: {level: 2},{/if}
+  items: [{foreach from=$items
+    item=i}{id: {$i.id}},{/foreach}]
+}
+</script>
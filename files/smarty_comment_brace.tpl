@@ -0,0 +1,11 @@
+{* disabled until the API is back
+<script type="text/javascript">
+var a = {ldelim}b: 1{rdelim};
+</script>
+*}
+{* <script> *} <p>{$c}</p>
+<style>body {margin: 0}</style>
+<div>{* <!-- *}</div>
+<script type="text/javascript">
+var d = {e: 2};
+</script>
@@ -0,0 +1,11 @@
+<script type="text/javascript">
+var cfg = {ldelim}
+  {include file="cfg.tpl"
+    assign=cfg}
+  user: {$user|json_encode},
+  {if $admin
+    && $debug}debug: {ldelim}level: 2{rdelim},{/if}
+  items: [{foreach from=$items
+    item=i}{ldelim}id: {$i.id}{rdelim},{/foreach}]
+{rdelim}
+</script>
@@ -0,0 +1,11 @@
+<script type="text/javascript">
+const greeting = `Hello ${user.name}, see http://example.com/{$path}`
+const card = `
+  <div class="card {$cls}">
+    <h1>${title}</h1> // not a comment
+    <p>${items.map(i => `<li>${i.label}</li>`).join('')}</p>
+  </div>
+`
+const style = { color: `${theme.color}` }
+let cfg = {a: `{b}`}
+</script>
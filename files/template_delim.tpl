@@ -0,0 +1,11 @@
+<script type="text/javascript">
+const greeting = `Hello ${ldelim}user.name{rdelim}, see http://example.com/{$path}`
+const card = `
+  <div class="card {$cls}">
+    <h1>${ldelim}title{rdelim}</h1> // not a comment
+    <p>${ldelim}items.map(i => `<li>${ldelim}i.label{rdelim}</li>`).join(''){rdelim}</p>
+  </div>
+`
+const style = {ldelim} color: `${ldelim}theme.color{rdelim}` {rdelim}
+let cfg = {ldelim}a: `{b}`{rdelim}
+</script>
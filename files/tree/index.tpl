@@ -0,0 +1,3 @@
+<script type="text/javascript">
+var config = {page: "index.tpl"};
+</script>
@@ -0,0 +1,3 @@
+<script type="text/javascript">
+var config = {page: "partials/legacy.tpl"};
+</script>
@@ -0,0 +1,6 @@
+<script type="text/javascript">
+const {a, b} = {$config|json}
+if (ready) {start()} else {wait({#delay#})}
+let label = {"Loading"|upper}, items = [{widget id=1}]
+{panel title="Help"}{help: true}{/panel}
+</script>
@@ -0,0 +1,6 @@
+<script type="text/javascript">
+const {ldelim}a, b{rdelim} = {$config|json}
+if (ready) {ldelim}start(){rdelim} else {ldelim}wait({#delay#}){rdelim}
+let label = {"Loading"|upper}, items = [{widget id=1}]
+{panel title="Help"}{ldelim}help: true{rdelim}{/panel}
+</script>
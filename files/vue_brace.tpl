@@ -0,0 +1,10 @@
+<div id="app">
+  <h1>{$title}</h1>
+  <li v-for="item in items">{{ item.name }} - {{item.price|currency}}</li>
+</div>
+<script type="text/x-template" id="row">
+  <tr><td>{{ row.id }}</td></tr>
+</script>
+<script type="text/javascript">
+new Vue({el: '#app', data: {items: {$items|json_encode}}, template: '<p>{{ msg }}</p>'})
+</script>
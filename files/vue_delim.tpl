@@ -0,0 +1,10 @@
+<div id="app">
+  <h1>{$title}</h1>
+  <li v-for="item in items">{ldelim}{ldelim} item.name {rdelim}{rdelim} - {ldelim}{ldelim}item.price|currency{rdelim}{rdelim}</li>
+</div>
+<script type="text/x-template" id="row">
+  <tr><td>{ldelim}{ldelim} row.id {rdelim}{rdelim}</td></tr>
+</script>
+<script type="text/javascript">
+new Vue({ldelim}el: '#app', data: {ldelim}items: {$items|json_encode}{rdelim}, template: '<p>{ldelim}{ldelim} msg {rdelim}{rdelim}</p>'{rdelim})
+</script>
@@ -0,0 +1,10 @@
+<div id="app">
+  <h1>{$title}</h1>
+  <li v-for="item in items">{literal}{{ item.name }}{/literal} - {literal}{{item.price|currency}}{/literal}</li>
+</div>
+<script type="text/x-template" id="row">
+  <tr><td>{literal}{{ row.id }}{/literal}</td></tr>
+</script>
+<script type="text/javascript">
+new Vue({ldelim}el: '#app', data: {ldelim}items: {$items|json_encode}{rdelim}, template: '<p>{literal}{{ msg }}{/literal}</p>'{rdelim})
+</script>
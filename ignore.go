@@ -0,0 +1,196 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ------------ IGNORE FILES
+
+// ignoreFileName is the ignore file looked up at the root of a tree run
+const ignoreFileName = ".smartybraceignore"
+
+// ignoreRule is a gitignore pattern, base being the directory it is
+// relative to
+type ignoreRule struct {
+	re      *regexp.Regexp
+	base    string
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher tells the paths of a tree run to skip, the last rule
+// matching a path deciding as with gitignore
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// add appends the gitignore patterns relative to the base directory
+func (m *ignoreMatcher) add(base string, patterns []string) {
+	for _, p := range patterns {
+		if rule, ok := newIgnoreRule(base, p); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+// addFile appends the patterns of the ignore file at path, relative to its
+// directory, a missing file adding none
+func (m *ignoreMatcher) addFile(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	m.add(filepath.Dir(path), patterns)
+
+	return nil
+}
+
+// ignores tells whether path, a directory when dir, is to be skipped
+func (m *ignoreMatcher) ignores(path string, dir bool) bool {
+	var ignored bool
+
+	path = filepath.ToSlash(path)
+
+	for _, rule := range m.rules {
+		if rule.dirOnly && !dir {
+			continue
+		}
+
+		if !strings.HasPrefix(path, rule.base+"/") {
+			continue
+		}
+
+		if rule.re.MatchString(strings.TrimPrefix(path, rule.base+"/")) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// newIgnoreRule compiles a gitignore pattern, blank lines and comments
+// making no rule
+func newIgnoreRule(base, pattern string) (ignoreRule, bool) {
+	rule := ignoreRule{base: strings.TrimSuffix(filepath.ToSlash(base), "/")}
+
+	pattern = strings.TrimRight(pattern, " \r")
+
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return rule, false
+	}
+
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+		pattern = pattern[1:]
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+
+	if pattern == "" {
+		return rule, false
+	}
+
+	// a pattern holding a slash is anchored to its base, otherwise it
+	// matches at any depth
+	prefix := `(?:.*/)?`
+
+	if strings.Contains(pattern, "/") {
+		prefix = ""
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+
+	rule.re = regexp.MustCompile("^" + prefix + globToRegexp(pattern) + "$")
+
+	return rule, true
+}
+
+// globToRegexp converts the wildcards of a gitignore pattern
+func globToRegexp(glob string) string {
+	var re string
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			re += `(?:.*/)?`
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			re += `.*`
+			i++
+		case c == '*':
+			re += `[^/]*`
+		case c == '?':
+			re += `[^/]`
+		case c == '\\' && i+1 < len(glob):
+			re += regexp.QuoteMeta(glob[i+1 : i+2])
+			i++
+		case c == '[':
+			end := strings.Index(glob[i:], "]")
+			if end < 0 {
+				re += `\[`
+				continue
+			}
+
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			re += "[" + strings.Replace(class, `\`, `\\`, -1) + "]"
+			i += end
+		default:
+			re += regexp.QuoteMeta(string(c))
+		}
+	}
+
+	return re
+}
+
+// repositoryRoot returns the nearest directory holding .git looking up from
+// dir
+func repositoryRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		dir = parent
+	}
+}
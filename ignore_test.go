@@ -0,0 +1,56 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := &ignoreMatcher{}
+	m.add("/tpl", []string{
+		"# comment",
+		"",
+		"templates_c/",
+		"/vendor",
+		"*.generated.tpl",
+		"!keep.generated.tpl",
+		"themes/**/old_*.tpl",
+		"draft?.tpl",
+		"[ab]side.tpl",
+	})
+
+	paths := []string{
+		"/tpl/templates_c",
+		"/tpl/admin/templates_c",
+		"/tpl/vendor",
+		"/tpl/widget.generated.tpl",
+		"/tpl/admin/list.generated.tpl",
+		"/tpl/themes/dark/admin/old_nav.tpl",
+		"/tpl/themes/old_nav.tpl",
+		"/tpl/draft1.tpl",
+		"/tpl/aside.tpl",
+	}
+
+	for _, path := range paths {
+		if !m.ignores(path, path == "/tpl/templates_c" || path == "/tpl/admin/templates_c") {
+			t.Fatalf("Expected path to be ignored: %s", path)
+		}
+	}
+
+	nonPaths := []string{
+		"/tpl/templates_c.tpl",
+		"/tpl/admin/vendor",
+		"/tpl/keep.generated.tpl",
+		"/tpl/old_nav.tpl",
+		"/tpl/draft12.tpl",
+		"/tpl/cside.tpl",
+		"/other/vendor",
+	}
+
+	for _, path := range nonPaths {
+		if m.ignores(path, path != "/tpl/templates_c.tpl") {
+			t.Fatalf("Expected path not to be ignored: %s", path)
+		}
+	}
+}
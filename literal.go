@@ -4,19 +4,353 @@
 
 package main
 
-import "regexp"
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
 
 // ------------ SCRIPT TAGS
-func startOfLiteralTag(line string) bool {
-	re := `{literal}(.+({\/literal}))?`
+func (d dialect) startOfLiteralTag(line string) bool {
+	open, end := regexp.QuoteMeta(d.literal[0]), regexp.QuoteMeta(d.literal[1])
+	re := open + `(.+(` + end + `))?`
 	match := regexp.MustCompile(re).FindStringSubmatch(line)
 
-	return match != nil && len(match) == 3 && match[2] != "{/literal}"
+	return match != nil && len(match) == 3 && match[2] != d.literal[1]
 }
 
-func endOfLiteralTag(line string) bool {
-	re := `(({literal).+)?{\/literal}`
+func (d dialect) endOfLiteralTag(line string) bool {
+	prefix := strings.TrimSuffix(d.literal[0], "}")
+	re := `((` + regexp.QuoteMeta(prefix) + `).+)?` + regexp.QuoteMeta(d.literal[1])
 	match := regexp.MustCompile(re).FindStringSubmatch(line)
 
-	return match != nil && len(match) == 3 && match[2] != "{literal"
+	return match != nil && len(match) == 3 && match[2] != prefix
+}
+
+// splitLiteralTags splits text around the literal tags of the dialect
+func (d dialect) splitLiteralTags(text string) []string {
+	var parts []string
+
+	for {
+		i, tag := -1, ""
+
+		for _, t := range d.literal {
+			if j := strings.Index(text, t); t != "" && j >= 0 && (i < 0 || j < i) {
+				i, tag = j, t
+			}
+		}
+
+		if i < 0 {
+			break
+		}
+
+		if i > 0 {
+			parts = append(parts, text[:i])
+		}
+
+		parts = append(parts, tag)
+		text = text[i+len(tag):]
+	}
+
+	if text != "" {
+		parts = append(parts, text)
+	}
+
+	return parts
+}
+
+func (d dialect) isLiteralTag(text string) bool {
+	return text != "" && (text == d.literal[0] || text == d.literal[1])
+}
+
+// outsideInlineLiterals applies convert to the text of line outside the
+// literal blocks opened and closed within it
+func (d dialect) outsideInlineLiterals(line string, convert func(string) string) string {
+	var nLine, text string
+	var inside bool
+
+	for _, part := range d.splitLiteralTags(line) {
+		switch {
+		case part == d.literal[0] && !inside:
+			nLine += convert(text) + part
+			text = ""
+			inside = true
+		case inside:
+			nLine += part
+			inside = part != d.literal[1]
+		default:
+			text += part
+		}
+	}
+
+	return nLine + convert(text)
+}
+
+// ------------ LITERAL WRAP
+
+// literalWrapper holds back runs of pure script lines, those without any
+// Smarty syntax, and writes them inside a {literal} block instead of inline
+// escapes once the run has at least threshold escapes
+type literalWrapper struct {
+	writer    *bufio.Writer
+	threshold int
+	dialect   dialect
+	raw       []string
+	parsed    []string
+	escapes   []int
+}
+
+// add queues a script line with its inline escaped form, split telling
+// whether it starts or ends within a template literal or a string, where a
+// literal tag would become part of the text
+func (lw *literalWrapper) add(raw, parsed string, split bool) {
+	escapes, pure := lw.pureScript(raw, parsed)
+
+	if split || !pure {
+		lw.write(parsed)
+		return
+	}
+
+	lw.raw = append(lw.raw, raw)
+	lw.parsed = append(lw.parsed, parsed)
+	lw.escapes = append(lw.escapes, escapes)
+}
+
+// write flushes the current run and writes line as is
+func (lw *literalWrapper) write(line string) {
+	lw.flush()
+	lw.writer.WriteString(line)
+}
+
+func (lw *literalWrapper) flush() {
+	first, last := -1, -1
+	var total int
+
+	for i, e := range lw.escapes {
+		if e == 0 {
+			continue
+		}
+
+		if first == -1 {
+			first = i
+		}
+
+		last = i
+		total += e
+	}
+
+	if total == 0 || total < lw.threshold {
+		for _, l := range lw.parsed {
+			lw.writer.WriteString(l)
+		}
+	} else {
+		indent := literalIndent(lw.raw[first : last+1])
+
+		for _, l := range lw.parsed[:first] {
+			lw.writer.WriteString(l)
+		}
+
+		lw.writer.WriteString(indent + lw.dialect.literal[0] + "\n")
+
+		for _, l := range lw.raw[first : last+1] {
+			lw.writer.WriteString(l)
+		}
+
+		if !strings.HasSuffix(lw.raw[last], "\n") {
+			lw.writer.WriteString("\n")
+		}
+
+		lw.writer.WriteString(indent + lw.dialect.literal[1] + "\n")
+
+		for _, l := range lw.parsed[last+1:] {
+			lw.writer.WriteString(l)
+		}
+	}
+
+	lw.raw = nil
+	lw.parsed = nil
+	lw.escapes = nil
+}
+
+// literalIndent is the shortest indentation among the non blank lines
+func literalIndent(lines []string) string {
+	var indent string
+	found := false
+	re := regexp.MustCompile(`^[ \t]*`)
+
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		i := re.FindString(l)
+
+		if !found || len(i) < len(indent) {
+			indent = i
+			found = true
+		}
+	}
+
+	return indent
+}
+
+// pureScript tells whether raw holds no Smarty syntax, that is every left
+// brace was escaped by the parse, and how many escapes the parse needed
+func (lw *literalWrapper) pureScript(raw, parsed string) (int, bool) {
+	if lw.dialect.hasEscapeTag(raw) || canonical.hasEscapeTag(raw) {
+		return 0, false
+	}
+
+	if strings.Contains(raw, "<script") || strings.Contains(raw, "</script") {
+		return 0, false
+	}
+
+	stripped, escapes := lw.dialect.stripEscapeTags(parsed)
+
+	for i := strings.Index(stripped, "{"); i != -1; i = strings.Index(stripped, "{") {
+		if !lw.dialect.autoLiteral || i+1 >= len(stripped) || !isSpace(stripped[i+1]) {
+			return 0, false
+		}
+
+		stripped = stripped[i+1:]
+	}
+
+	return escapes, true
+}
+
+// ------------ LITERAL UNWRAP
+
+// unwrapLiteralBlock drops the {literal} and {/literal} tags around a block,
+// lines holds the whole block from the opening to the closing tag line, a
+// block opened and closed on the same line being a single line from one tag
+// to the other. The block is returned untouched when it holds Smarty syntax,
+// otherwise unless force is set or every brace inside is followed by
+// whitespace on an autoLiteral dialect
+func (d dialect) unwrapLiteralBlock(lines []string, force bool) ([]string, bool) {
+	if len(lines) == 1 {
+		content := strings.TrimSuffix(strings.TrimPrefix(lines[0], d.literal[0]), d.literal[1])
+
+		if d.holdsSmarty([]string{content}) {
+			return lines, false
+		}
+
+		if !force && !(d.autoLiteral && d.autoLiteralSafe([]string{content})) {
+			return lines, false
+		}
+
+		return []string{content}, true
+	}
+
+	if len(lines) < 2 {
+		return lines, false
+	}
+
+	content := lines[1 : len(lines)-1]
+
+	// the text sharing a line with the tags is unwrapped as well
+	head, tail := lines[0], lines[len(lines)-1]
+	inner := append([]string{head[strings.Index(head, d.literal[0])+len(d.literal[0]):]}, content...)
+	inner = append(inner, tail[:strings.LastIndex(tail, d.literal[1])])
+
+	if d.holdsSmarty(inner) {
+		return lines, false
+	}
+
+	if !force && !(d.autoLiteral && d.autoLiteralSafe(inner)) {
+		return lines, false
+	}
+
+	var nLines []string
+
+	first := strings.Replace(lines[0], d.literal[0], "", 1)
+	if strings.TrimSpace(first) != "" {
+		nLines = append(nLines, first)
+	}
+
+	nLines = append(nLines, content...)
+
+	last := lines[len(lines)-1]
+	i := strings.LastIndex(last, d.literal[1])
+	last = last[:i] + last[i+len(d.literal[1]):]
+
+	if strings.TrimSpace(last) != "" {
+		nLines = append(nLines, last)
+	}
+
+	return nLines, true
+}
+
+// unwrapInlineLiterals runs unwrapLiteralBlock over each literal block opened
+// and closed within line, the content of those unwrapped being returned
+func (d dialect) unwrapInlineLiterals(line string, force bool) (string, []string) {
+	var nLine, block string
+	var contents []string
+
+	for _, part := range d.splitLiteralTags(line) {
+		switch {
+		case part == d.literal[0] && block == "":
+			block = part
+		case block != "":
+			block += part
+
+			if part != d.literal[1] {
+				continue
+			}
+
+			lines, unwrapped := d.unwrapLiteralBlock([]string{block}, force)
+			if unwrapped {
+				contents = append(contents, lines[0])
+			}
+
+			nLine += lines[0]
+			block = ""
+		default:
+			nLine += part
+		}
+	}
+
+	return nLine + block, contents
+}
+
+// holdsSmarty tells whether lines hold anything Smarty would parse once out
+// of a literal block: a tag, a comment, an escape or a literal tag
+func (d dialect) holdsSmarty(lines []string) bool {
+	c := d.withCanonical()
+
+	for _, l := range lines {
+		for i := strings.IndexByte(l, '{'); i >= 0; i = strings.IndexByte(l, '{') {
+			l = l[i:]
+
+			if _, _, ok := c.escapeTagAt(l); ok || d.isSmartyTagStart(l) || strings.HasPrefix(l, "{*") {
+				return true
+			}
+
+			if strings.HasPrefix(l, d.literal[0]) || strings.HasPrefix(l, d.literal[1]) {
+				return true
+			}
+
+			l = l[1:]
+		}
+	}
+
+	return false
+}
+
+// autoLiteralSafe tells whether lines render the same outside {literal} on
+// an auto_literal engine, that is every left brace is followed by whitespace
+// and no escape tag is present
+func (d dialect) autoLiteralSafe(lines []string) bool {
+	for _, l := range lines {
+		if d.hasEscapeTag(l) {
+			return false
+		}
+
+		for i := 0; i < len(l); i++ {
+			if l[i] == '{' && (i+1 >= len(l) || !isSpace(l[i+1])) {
+				return false
+			}
+		}
+	}
+
+	return true
 }
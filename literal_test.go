@@ -4,7 +4,12 @@
 
 package main
 
-import "testing"
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
 
 // ------------ SCRIPT TAGS
 var openLiteralTags = []string{
@@ -56,7 +61,7 @@ var nonLiteralTags = []string{
 
 func TestStartLiteralTags(t *testing.T) {
 	for _, line := range openLiteralTags {
-		if !startOfLiteralTag(line) {
+		if !dialects["smarty2"].startOfLiteralTag(line) {
 			t.Fatalf("Should be literal tag %s", line)
 		}
 	}
@@ -64,7 +69,7 @@ func TestStartLiteralTags(t *testing.T) {
 
 func TestStartLiteralTagNonTags(t *testing.T) {
 	for _, line := range closeLiteralTags {
-		if startOfLiteralTag(line) {
+		if dialects["smarty2"].startOfLiteralTag(line) {
 			t.Fatalf("Should not be literal tag %s", line)
 		}
 	}
@@ -72,7 +77,7 @@ func TestStartLiteralTagNonTags(t *testing.T) {
 
 func TestEndLiteralTags(t *testing.T) {
 	for _, line := range closeLiteralTags {
-		if !endOfLiteralTag(line) {
+		if !dialects["smarty2"].endOfLiteralTag(line) {
 			t.Fatalf("Should be literal tag %s", line)
 		}
 	}
@@ -80,8 +85,204 @@ func TestEndLiteralTags(t *testing.T) {
 
 func TestEndLiteralTagNonTags(t *testing.T) {
 	for _, line := range nonLiteralTags {
-		if endOfLiteralTag(line) {
+		if dialects["smarty2"].endOfLiteralTag(line) {
 			t.Fatalf("Should not be literal tag %s", line)
 		}
 	}
 }
+
+// ------------ LITERAL WRAP
+
+var pureScriptLines = [][]string{
+	[]string{"call({\n", "call({ldelim}\n"},
+	[]string{"  hello: \"world\"\n", "  hello: \"world\"\n"},
+	[]string{"}, {\n", "{rdelim}, {ldelim}\n"},
+	[]string{"})\n", "{rdelim})\n"},
+}
+
+var nonPureScriptLines = [][]string{
+	[]string{"let myVar = {json_decode($jsonVariable)}\n", "let myVar = {json_decode($jsonVariable)}\n"},
+	[]string{"let myOtherVar = '{$wuuuu}'\n", "let myOtherVar = '{$wuuuu}'\n"},
+	[]string{"console.log(\"{ldelim}\")\n", "console.log(\"{ldelim}\")\n"},
+	[]string{"{* comment *}\n", "{* comment *}\n"},
+	[]string{"<script>var a = {}\n", "<script>var a = {ldelim}{rdelim}\n"},
+	[]string{"}</script>\n", "{rdelim}</script>\n"},
+}
+
+func TestLiteralWrapperPureScript(t *testing.T) {
+	lw := &literalWrapper{dialect: dialects["smarty2"]}
+
+	for _, l := range pureScriptLines {
+		if _, pure := lw.pureScript(l[0], l[1]); !pure {
+			t.Fatalf("Should be pure script %s", l[0])
+		}
+	}
+
+	for _, l := range nonPureScriptLines {
+		if _, pure := lw.pureScript(l[0], l[1]); pure {
+			t.Fatalf("Should not be pure script %s", l[0])
+		}
+	}
+}
+
+func TestLiteralWrapperAutoLiteral(t *testing.T) {
+	lw := &literalWrapper{dialect: dialects["smarty3"]}
+
+	if _, pure := lw.pureScript("call({\n", "call({\n"); !pure {
+		t.Fatal("Should be pure script under auto literal")
+	}
+
+	if _, pure := lw.pureScript("call({a: 1})\n", "call({a: 1})\n"); pure {
+		t.Fatal("Should not be pure script under auto literal")
+	}
+}
+
+func TestLiteralWrapperThreshold(t *testing.T) {
+	var out bytes.Buffer
+
+	writer := bufio.NewWriter(&out)
+	lw := &literalWrapper{writer: writer, threshold: 5, dialect: dialects["smarty2"]}
+
+	for _, l := range pureScriptLines {
+		lw.add(l[0], l[1], false)
+	}
+
+	lw.write("</script>\n")
+	writer.Flush()
+
+	exp := "call({ldelim}\n  hello: \"world\"\n{rdelim}, {ldelim}\n{rdelim})\n</script>\n"
+
+	if out.String() != exp {
+		t.Fatalf("Expected inline escapes: %s; got: %s", exp, out.String())
+	}
+
+	out.Reset()
+	lw.threshold = 4
+
+	for _, l := range pureScriptLines {
+		lw.add(l[0], l[1], false)
+	}
+
+	lw.write("</script>\n")
+	writer.Flush()
+
+	exp = "{literal}\ncall({\n  hello: \"world\"\n}, {\n})\n{/literal}\n</script>\n"
+
+	if out.String() != exp {
+		t.Fatalf("Expected literal wrap: %s; got: %s", exp, out.String())
+	}
+}
+
+// ------------ LITERAL UNWRAP
+
+var literalBlock = []string{
+	"  {literal}\n",
+	"  call({\n",
+	"    hello: \"world\"\n",
+	"  })\n",
+	"  {/literal}\n",
+}
+
+var unsafeLiteralBlock = []string{
+	"{literal}\n",
+	"var o = {}\n",
+	"{/literal} // done\n",
+}
+
+func TestUnwrapLiteralBlockAutoLiteral(t *testing.T) {
+	lines, unwrapped := dialects["smarty3"].unwrapLiteralBlock(literalBlock, false)
+	exp := literalBlock[1:4]
+
+	if !unwrapped || strings.Join(lines, "") != strings.Join(exp, "") {
+		t.Fatalf("Expected unwrapped block: %s; got: %s", exp, lines)
+	}
+
+	lines, unwrapped = dialects["smarty3"].unwrapLiteralBlock(unsafeLiteralBlock, false)
+
+	if unwrapped || strings.Join(lines, "") != strings.Join(unsafeLiteralBlock, "") {
+		t.Fatalf("Expected block to be untouched; got: %s", lines)
+	}
+}
+
+func TestUnwrapLiteralBlockForce(t *testing.T) {
+	lines, unwrapped := dialects["smarty2"].unwrapLiteralBlock(unsafeLiteralBlock, true)
+	exp := "var o = {}\n // done\n"
+
+	if !unwrapped || strings.Join(lines, "") != exp {
+		t.Fatalf("Expected unwrapped block: %s; got: %s", exp, lines)
+	}
+
+	lines, unwrapped = dialects["smarty2"].unwrapLiteralBlock(literalBlock, false)
+
+	if unwrapped || strings.Join(lines, "") != strings.Join(literalBlock, "") {
+		t.Fatalf("Expected block to be untouched; got: %s", lines)
+	}
+}
+
+func TestUnwrapLiteralBlockSmarty(t *testing.T) {
+	blocks := [][]string{
+		{`{literal}var tpl = "<b>{$price}</b>";{/literal}`},
+		{"{literal}\n", "if (a) {if (b) {c()}}\n", "{/literal}\n"},
+		{"{literal}\n", "var s = \"{ldelim}\" {* note *}\n", "{/literal}\n"},
+	}
+
+	for _, block := range blocks {
+		if lines, unwrapped := dialects["smarty2"].unwrapLiteralBlock(block, true); unwrapped {
+			t.Fatalf("Expected block holding Smarty syntax to be kept; got: %s", lines)
+		}
+	}
+}
+
+func TestUnwrapLiteralBlockSameLine(t *testing.T) {
+	lines, unwrapped := dialects["smarty3"].unwrapLiteralBlock([]string{"{literal}{ a: 1 }{/literal}"}, false)
+
+	if !unwrapped || strings.Join(lines, "") != "{ a: 1 }" {
+		t.Fatalf("Expected unwrapped block: { a: 1 }; got: %s", lines)
+	}
+
+	lines, unwrapped = dialects["smarty3"].unwrapLiteralBlock([]string{"{literal}{a: 1}{/literal}"}, false)
+
+	if unwrapped || strings.Join(lines, "") != "{literal}{a: 1}{/literal}" {
+		t.Fatalf("Expected block to be untouched; got: %s", lines)
+	}
+}
+
+func TestUnwrapInlineLiterals(t *testing.T) {
+	line := "var a = {literal}{ b: 1 }{/literal}, c = {literal}{d: 2}{/literal};\n"
+
+	nl, contents := dialects["smarty3"].unwrapInlineLiterals(line, false)
+	exp := "var a = { b: 1 }, c = {literal}{d: 2}{/literal};\n"
+
+	if nl != exp || len(contents) != 1 {
+		t.Fatalf("Expected only the safe block unwrapped: %s; got: %s %v", exp, nl, contents)
+	}
+
+	nl, contents = dialects["smarty2"].unwrapInlineLiterals(line, true)
+	exp = "var a = { b: 1 }, c = {d: 2};\n"
+
+	if nl != exp || len(contents) != 2 {
+		t.Fatalf("Expected every block unwrapped: %s; got: %s %v", exp, nl, contents)
+	}
+}
+
+func TestSplitLiteralTags(t *testing.T) {
+	d := dialects["smarty2"]
+	text := "  <b>{literal}${a}{/literal}</b>{literal}"
+	exp := []string{"  <b>", "{literal}", "${a}", "{/literal}", "</b>", "{literal}"}
+
+	parts := d.splitLiteralTags(text)
+
+	if len(parts) != len(exp) {
+		t.Fatalf("Expected parts: %q; got: %q", exp, parts)
+	}
+
+	for i, p := range parts {
+		if p != exp[i] {
+			t.Fatalf("Expected part: %q; got: %q", exp[i], p)
+		}
+
+		if d.isLiteralTag(p) != (p == "{literal}" || p == "{/literal}") {
+			t.Fatalf("Expected %q literal tag: %t", p, !d.isLiteralTag(p))
+		}
+	}
+}
@@ -5,30 +5,93 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
 )
 
 var inputArg = flag.String("i", "", "Input file path")
-var outputArg = flag.String("o", "", "Output file path (if not provied will overwrite input file)")
+var outputArg = flag.String("o", "", "Output file path, the input file being backed up (if not provided the result goes to stdout)")
+var listArg = flag.Bool("l", false, "List the files whose content the parse would change")
+var diffArg = flag.Bool("diff", false, "Print the diffs of the parse, -d being the delim parse")
+var writeArg = flag.Bool("w", false, "Write the result to the input file, without backup")
+var autoArg = flag.String("auto", "", "Convert each file towards the target form, bare or escaped, whatever form it is in, files mixing both are reported and left unchanged")
 var braceArg = flag.Bool("b", false, "Parse braces into {delim}")
 var delimArg = flag.Bool("d", false, "Parse {delim} into braces")
 var rmArg = flag.Bool("rm", false, "Remove backup file after parse")
 var owArg = flag.Bool("ow", false, "Overwrite backup file if already exist")
+var dialectArg = flag.String("dialect", "", "Template dialect: smarty2, smarty3, smarty4 (only escape braces auto_literal would parse) or latte, detected from composer.lock, composer.json or Smarty.class.php if not provided, otherwise smarty2")
+var strategyArg = flag.String("strategy", "inline", "Brace parse strategy: inline ({ldelim} and {rdelim}) or literal (wrap pure script lines in {literal})")
+var thresholdArg = flag.Int("threshold", 4, "Minimum escapes a run of script lines must need to be wrapped in {literal}")
+var unwrapArg = flag.Bool("unwrap", false, "Remove {literal} wrappers within scripts on delim parse")
+var styleArg = flag.String("style", "", "Escape tags emitted: delim ({ldelim}), smarty ({$smarty.ldelim}) or quote ({'{'}) (default to the first of the dialect)")
+var policyArg = flag.String("policy", "", "Comma separated context=policy list, contexts: string, template, line, block, regex; policies: keep, convert, escape (default string=escape, others keep)")
+var pluginsArg = flag.String("plugins", "", "Comma separated Smarty plugin names or plugins directories, recognised as tags along with the built-in functions")
+var mustacheArg = flag.String("mustache", "", "Escape Vue or Angular {{ }} interpolations anywhere in the template: inline ({ldelim}{ldelim}) or literal (wrap in {literal})")
+var dialectFileArg = flag.String("dialect-file", "", "JSON dialect definition of another template engine escape and literal tags")
+var gitignoreArg = flag.Bool("gitignore", false, "Skip the files ignored by the .gitignore files of the repository when the input is a directory")
+var excludeArg listFlag
+
+// stdout and stderr are where results and reports go
+var stdout io.Writer = os.Stdout
+var stderr io.Writer = os.Stderr
+
+func init() {
+	flag.Var(&excludeArg, "exclude", "Gitignore pattern of the files to skip when the input is a directory, may be repeated")
+}
+
+// listFlag is a flag that may be given several times
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+
+	return nil
+}
 
 func main() {
-	flag.Parse()
+	var normalizeCmd bool
+
+	if len(os.Args) > 1 && os.Args[1] == "normalize" {
+		normalizeCmd = true
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
 
 	args := map[string]interface{}{
-		"backupSuffix": "_backup",
-		"inputPath":    *inputArg,
-		"outputPath":   *outputArg,
-		"removeBackup": *rmArg,
-		"overWrite":    *owArg,
-		"brace":      *braceArg,
-		"delim":        *delimArg,
+		"backupSuffix":     "_backup",
+		"inputPath":        *inputArg,
+		"outputPath":       *outputArg,
+		"removeBackup":     *rmArg,
+		"overWrite":        *owArg,
+		"brace":            *braceArg,
+		"delim":            *delimArg,
+		"dialect":          *dialectArg,
+		"strategy":         *strategyArg,
+		"literalThreshold": *thresholdArg,
+		"unwrap":           *unwrapArg,
+		"style":            *styleArg,
+		"dialectFile":      *dialectFileArg,
+		"policy":           *policyArg,
+		"plugins":          *pluginsArg,
+		"mustache":         *mustacheArg,
+		"exclude":          []string(excludeArg),
+		"gitignore":        *gitignoreArg,
+		"list":             *listArg,
+		"diff":             *diffArg,
+		"write":            *writeArg,
+		"auto":             *autoArg,
+		"normalize":        normalizeCmd,
 	}
 
 	code, err := altMain(args)
@@ -44,22 +107,78 @@ func altMain(args map[string]interface{}) (int, error) {
 	backupSuffix := args["backupSuffix"].(string)
 	inputPath := args["inputPath"].(string)
 	outputPath := args["outputPath"].(string)
-	removeBackup := args["removeBackup"].(bool)
-	overWrite := args["overWrite"].(bool)
 	brace := args["brace"].(bool)
 	delim := args["delim"].(bool)
+	normalizeCmd := args["normalize"].(bool)
+	auto := args["auto"].(string)
+
+	if _, ok := autoTargets[auto]; auto != "" && !ok {
+		return 1, fmt.Errorf("Unknown auto target: %s", auto)
+	}
 
-	if !brace && !delim {
+	if normalizeCmd {
+		if brace || delim {
+			return 1, errors.New("Normalize does not take a delim or brace parse")
+		}
+
+		if auto != "" {
+			return 1, errors.New("Normalize does not take an auto target")
+		}
+	} else if auto != "" {
+		if brace || delim {
+			return 1, errors.New("Auto does not take a delim or brace parse")
+		}
+	} else if !brace && !delim {
 		return 1, errors.New("Must choose an type of action delim or brace parse")
 	} else if brace && delim {
 
 		return 1, errors.New("Must choose between delim or brace parse, not both")
 	}
 
-	if outputPath == "" {
-		outputPath = inputPath
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		return 1, err
+	}
+
+	if outputPath != "" && (args["list"].(bool) || args["diff"].(bool) || args["write"].(bool)) {
+		return 1, errors.New("Output path can not be given along with -l, -diff or -w")
 	}
 
+	files := []string{inputPath}
+
+	if info, err := os.Stat(inputPath); err == nil && info.IsDir() {
+		if outputPath != "" {
+			return 1, errors.New("Output path can not be given for a directory input")
+		}
+
+		files, err = walkTemplates(inputPath, opts.dialect, backupSuffix, args["exclude"].([]string), args["gitignore"].(bool))
+		if err != nil {
+			return 7, fmt.Errorf("Error ocurred walking directory: %s", err)
+		}
+	}
+
+	for _, path := range files {
+		parse := formatFile
+
+		if outputPath != "" {
+			parse = parseFile
+		}
+
+		if code, err := parse(path, outputPath, args, opts); err != nil {
+			return code, err
+		}
+	}
+
+	return 0, nil
+}
+
+// parseFile runs the parse chosen by args over the file at inputPath,
+// writing it to outputPath once backed up
+func parseFile(inputPath, outputPath string, args map[string]interface{}, opts options) (int, error) {
+	backupSuffix := args["backupSuffix"].(string)
+	removeBackup := args["removeBackup"].(bool)
+	overWrite := args["overWrite"].(bool)
+
 	backup, err := createBackup(inputPath, backupSuffix, overWrite)
 	if err != nil {
 		return 2, fmt.Errorf("Error ocurred during backup creation: %s", err)
@@ -72,34 +191,114 @@ func altMain(args map[string]interface{}) (int, error) {
 	}
 
 	outputFile, err := os.Create(outputPath)
-	defer inputFile.Close()
+	defer outputFile.Close()
 	if err != nil {
 		return 4, fmt.Errorf("Error ocurred creating output file: %s", err)
 	}
 
-	if brace {
-		err = parseBraces(inputFile, outputFile)
-	} else if delim {
-		err = parseDelims(inputFile, outputFile)
+	if err := runParse(inputFile, outputFile, inputPath, stdout, args, opts); err != nil {
+		return 5, err
 	}
 
-	if err != nil {
-		t := "brace"
+	if removeBackup {
+		err = os.Remove(inputFile.Name())
 
-		if delim {
-			t = "delim"
+		if err != nil {
+			return 6, fmt.Errorf("Error ocurred trying to remove backup file %s", err)
 		}
+	}
 
-		return 5, fmt.Errorf("Error during %s parse operation: %s", t, err)
+	return 0, nil
+}
+
+// formatFile runs the parse chosen by args over the file at path the way
+// gofmt does: listing it when it would change, printing its diff, writing
+// it in place or, by default, printing the result
+func formatFile(path, _ string, args map[string]interface{}, opts options) (int, error) {
+	var result bytes.Buffer
+
+	list := args["list"].(bool)
+	diff := args["diff"].(bool)
+	write := args["write"].(bool)
+
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 3, fmt.Errorf("Error ocurred while trying to read input file: %s", err)
 	}
 
-	if removeBackup {
-		err = os.Remove(inputFile.Name())
+	if err := runParse(bytes.NewReader(src), &result, path, stderr, args, opts); err != nil {
+		return 5, err
+	}
 
+	if !list && !diff && !write {
+		stdout.Write(result.Bytes())
+		return 0, nil
+	}
+
+	if bytes.Equal(src, result.Bytes()) {
+		return 0, nil
+	}
+
+	if list {
+		fmt.Fprintln(stdout, path)
+	}
+
+	if write {
+		info, err := os.Stat(path)
 		if err != nil {
-			return 6, fmt.Errorf("Error ocurred trying to remove backup file %s", err)
+			return 4, fmt.Errorf("Error ocurred writing input file: %s", err)
+		}
+
+		if err := ioutil.WriteFile(path, result.Bytes(), info.Mode()); err != nil {
+			return 4, fmt.Errorf("Error ocurred writing input file: %s", err)
 		}
 	}
 
+	if diff {
+		fmt.Fprint(stdout, unifiedDiff(path+".orig", path, src, result.Bytes()))
+	}
+
 	return 0, nil
 }
+
+// runParse runs the parse chosen by args from input into output, the
+// normalize counts of path or its mixed forms being reported to report
+func runParse(input io.Reader, output io.Writer, path string, report io.Writer, args map[string]interface{}, opts options) error {
+	var err error
+
+	brace := args["brace"].(bool)
+	delim := args["delim"].(bool)
+	normalizeCmd := args["normalize"].(bool)
+	auto := args["auto"].(string)
+
+	if normalizeCmd {
+		var counts *styleCounts
+
+		counts, err = normalize(input, output, opts)
+		if err == nil {
+			fmt.Fprintf(report, "Normalized %s: %s\n", path, counts)
+		}
+	} else if auto != "" {
+		err = autoParse(input, output, path, report, auto, opts)
+	} else if brace {
+		err = parseBraces(input, output, opts)
+	} else if delim {
+		err = parseDelims(input, output, opts)
+	}
+
+	if err != nil {
+		t := "brace"
+
+		if delim {
+			t = "delim"
+		} else if normalizeCmd {
+			t = "normalize"
+		} else if auto != "" {
+			t = "auto"
+		}
+
+		return fmt.Errorf("Error during %s parse operation: %s", t, err)
+	}
+
+	return nil
+}
@@ -5,19 +5,39 @@
 package main
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func getCommonFlags() map[string]interface{} {
 	return map[string]interface{}{
-		"backupSuffix": "_backup",
-		"inputPath":    "",
-		"outputPath":   "",
-		"removeBackup": false,
-		"overWrite":    false,
-		"brace":      false,
-		"delim":        false,
+		"backupSuffix":     "_backup",
+		"inputPath":        "",
+		"outputPath":       "",
+		"removeBackup":     false,
+		"overWrite":        false,
+		"brace":            false,
+		"delim":            false,
+		"dialect":          "",
+		"strategy":         "",
+		"literalThreshold": 0,
+		"unwrap":           false,
+		"style":            "",
+		"dialectFile":      "",
+		"policy":           "",
+		"plugins":          "",
+		"mustache":         "",
+		"exclude":          []string(nil),
+		"gitignore":        false,
+		"list":             false,
+		"diff":             false,
+		"write":            false,
+		"auto":             "",
+		"normalize":        false,
 	}
 }
 
@@ -176,3 +196,273 @@ func TestMainRemoveBackup(t *testing.T) {
 		t.Fatal("Expected backup file to not exist")
 	}
 }
+
+func TestMainNormalizeWithParseOption(t *testing.T) {
+	cflags := getCommonFlags()
+	cflags["normalize"] = true
+	cflags["brace"] = true
+
+	expCode := 1
+	expErr := "Normalize does not take a delim or brace parse"
+
+	code, err := altMain(cflags)
+
+	if code != expCode {
+		t.Fatalf("Expected exit code: %d; got: %d", expCode, code)
+	}
+
+	if err == nil || err.Error() != expErr {
+		t.Fatalf("Expected error: %s; got: %v", expErr, err)
+	}
+}
+
+func TestMainDirectoryWithOutput(t *testing.T) {
+	cflags := getCommonFlags()
+	cflags["brace"] = true
+	cflags["inputPath"] = "files/tree"
+	cflags["outputPath"] = "files/tree_parsed"
+
+	expCode := 1
+	expErr := "Output path can not be given for a directory input"
+
+	code, err := altMain(cflags)
+
+	if code != expCode {
+		t.Fatalf("Expected exit code: %d; got: %d", expCode, code)
+	}
+
+	if err == nil || err.Error() != expErr {
+		t.Fatalf("Expected error: %s; got: %v", expErr, err)
+	}
+}
+
+func TestMainDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smarty-brace-delim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{".smartybraceignore", "index.tpl", "templates_c/cache.tpl"} {
+		content, err := ioutil.ReadFile(filepath.Join("files/tree", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cflags := getCommonFlags()
+	cflags["brace"] = true
+	cflags["write"] = true
+	cflags["inputPath"] = dir
+
+	code, err := altMain(cflags)
+
+	if code != 0 {
+		t.Fatalf("Expected exit code: 0; got: %d (%s)", code, err)
+	}
+
+	expected := map[string]string{
+		"index.tpl":             "var config = {ldelim}page: \"index.tpl\"{rdelim};",
+		"templates_c/cache.tpl": "var config = {page: \"templates_c/cache.tpl\"};",
+	}
+
+	for name, line := range expected {
+		content, _ := ioutil.ReadFile(filepath.Join(dir, name))
+
+		if !strings.Contains(string(content), line) {
+			t.Fatalf("Expected %s to hold: %s; got: %s", name, line, content)
+		}
+	}
+}
+
+// testFormatMode runs a brace parse over a copy of files/simple_brace.tpl
+// with the given modes, returning the copy path and what went to stdout
+func testFormatMode(t *testing.T, modes ...string) (string, string) {
+	var out bytes.Buffer
+
+	dir, err := ioutil.TempDir("", "smarty-brace-delim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile("files/simple_brace.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "simple_brace.tpl")
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cflags := getCommonFlags()
+	cflags["brace"] = true
+	cflags["inputPath"] = path
+
+	for _, m := range modes {
+		cflags[m] = true
+	}
+
+	stdout = &out
+	defer func() { stdout = os.Stdout }()
+
+	if code, err := altMain(cflags); code != 0 {
+		t.Fatalf("Expected exit code: 0; got: %d (%s)", code, err)
+	}
+
+	return path, out.String()
+}
+
+func TestMainStdout(t *testing.T) {
+	path, out := testFormatMode(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	exp, _ := ioutil.ReadFile("files/simple_delim.tpl")
+
+	if out != string(exp) {
+		t.Fatalf("Expected stdout: %s; got: %s", exp, out)
+	}
+
+	if content, _ := ioutil.ReadFile(path); string(content) == out {
+		t.Fatal("Expected input file to be left untouched")
+	}
+}
+
+func TestMainList(t *testing.T) {
+	path, out := testFormatMode(t, "list")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if out != path+"\n" {
+		t.Fatalf("Expected listed file: %s; got: %s", path, out)
+	}
+}
+
+func TestMainDiff(t *testing.T) {
+	path, out := testFormatMode(t, "diff")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if !strings.HasPrefix(out, "diff "+path+".orig "+path+"\n") || !strings.Contains(out, "\n+") {
+		t.Fatalf("Expected diff of %s; got: %s", path, out)
+	}
+}
+
+func TestMainWrite(t *testing.T) {
+	path, out := testFormatMode(t, "write", "list")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	exp, _ := ioutil.ReadFile("files/simple_delim.tpl")
+
+	if content, _ := ioutil.ReadFile(path); string(content) != string(exp) {
+		t.Fatalf("Expected written file: %s; got: %s", exp, content)
+	}
+
+	if out != path+"\n" {
+		t.Fatalf("Expected listed file: %s; got: %s", path, out)
+	}
+
+	if files, _ := ioutil.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Fatalf("Expected no backup file; got: %d files", len(files))
+	}
+
+	// a second run finds nothing to change
+	cflags := getCommonFlags()
+	cflags["brace"] = true
+	cflags["list"] = true
+	cflags["inputPath"] = path
+
+	var second bytes.Buffer
+
+	stdout = &second
+	defer func() { stdout = os.Stdout }()
+
+	altMain(cflags)
+
+	if second.Len() != 0 {
+		t.Fatalf("Expected no file listed; got: %s", second.String())
+	}
+}
+
+func TestMainOutputWithMode(t *testing.T) {
+	cflags := getCommonFlags()
+	cflags["brace"] = true
+	cflags["write"] = true
+	cflags["inputPath"] = "files/simple_brace.tpl"
+	cflags["outputPath"] = "files/simple_brace_parsed.tpl"
+
+	expErr := "Output path can not be given along with -l, -diff or -w"
+
+	if code, err := altMain(cflags); code != 1 || err == nil || err.Error() != expErr {
+		t.Fatalf("Expected exit code 1 with error: %s; got: %d %v", expErr, code, err)
+	}
+}
+
+func TestMainAuto(t *testing.T) {
+	var out, report bytes.Buffer
+
+	dir, err := ioutil.TempDir("", "smarty-brace-delim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"simple_brace.tpl", "simple_delim.tpl", "auto_mixed.tpl"} {
+		content, err := ioutil.ReadFile(filepath.Join("files", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cflags := getCommonFlags()
+	cflags["auto"] = "escaped"
+	cflags["list"] = true
+	cflags["inputPath"] = dir
+
+	stdout, stderr = &out, &report
+	defer func() { stdout, stderr = os.Stdout, os.Stderr }()
+
+	if code, err := altMain(cflags); code != 0 {
+		t.Fatalf("Expected exit code: 0; got: %d (%s)", code, err)
+	}
+
+	expOut := filepath.Join(dir, "simple_brace.tpl") + "\n"
+	expReport := "Mixed bare and escaped braces, left for review: " + filepath.Join(dir, "auto_mixed.tpl") + "\n"
+
+	if out.String() != expOut {
+		t.Fatalf("Expected listed files: %s; got: %s", expOut, out.String())
+	}
+
+	if report.String() != expReport {
+		t.Fatalf("Expected report: %s; got: %s", expReport, report.String())
+	}
+}
+
+func TestMainAutoWithParseOption(t *testing.T) {
+	cflags := getCommonFlags()
+	cflags["auto"] = "escaped"
+	cflags["brace"] = true
+
+	expErr := "Auto does not take a delim or brace parse"
+
+	if code, err := altMain(cflags); code != 1 || err == nil || err.Error() != expErr {
+		t.Fatalf("Expected exit code 1 with error: %s; got: %d %v", expErr, code, err)
+	}
+
+	cflags["brace"] = false
+	cflags["auto"] = "braces"
+
+	expErr = "Unknown auto target: braces"
+
+	if code, err := altMain(cflags); code != 1 || err == nil || err.Error() != expErr {
+		t.Fatalf("Expected exit code 1 with error: %s; got: %d %v", expErr, code, err)
+	}
+}
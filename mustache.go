@@ -0,0 +1,93 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ------------ MUSTACHE
+
+// mustacheRe matches the {{ }} interpolations of Vue or Angular templates,
+// leaving a Smarty variable wrapped in braces such as {{$var}}
+var mustacheRe = regexp.MustCompile(`\{\{[^$].*?\}\}`)
+
+// escapeMustache escapes the interpolation text either inline with the tags
+// of style or wrapped in a literal block
+func (d dialect) escapeMustache(text, form, style string) string {
+	if form == "literal" {
+		return d.literal[0] + text + d.literal[1]
+	}
+
+	tags, _ := d.escapeStyle(style)
+	inner := text[2 : len(text)-2]
+
+	return tags.Left + tags.Left + inner + tags.Right + tags.Right
+}
+
+// literalMustacheRe matches the interpolations wrapped in a literal block
+// of the dialect, nil when it has none
+func (d dialect) literalMustacheRe() *regexp.Regexp {
+	if d.literal[0] == "" {
+		return nil
+	}
+
+	return regexp.MustCompile(regexp.QuoteMeta(d.literal[0]) + `(\{\{[^$].*?\}\})` + regexp.QuoteMeta(d.literal[1]))
+}
+
+// maskMustaches replaces the interpolations of line by [MST-n] fragments
+// holding their escaped form, those already wrapped in a literal block
+// being kept as they are
+func (d dialect) maskMustaches(line, form, style string) (string, []string) {
+	var fragments []string
+
+	re := mustacheRe
+
+	if lit := d.literalMustacheRe(); lit != nil {
+		re = regexp.MustCompile(lit.String() + "|" + mustacheRe.String())
+	}
+
+	line = re.ReplaceAllStringFunc(line, func(m string) string {
+		if mustacheRe.FindString(m) == m {
+			m = d.escapeMustache(m, form, style)
+		}
+
+		fragments = append(fragments, m)
+
+		return "[MST-" + strconv.Itoa(len(fragments)-1) + "]"
+	})
+
+	return line, fragments
+}
+
+// restoreMustaches puts the escaped interpolations back into line
+func restoreMustaches(line string, fragments []string) string {
+	for i, v := range fragments {
+		line = strings.Replace(line, "[MST-"+strconv.Itoa(i)+"]", v, 1)
+	}
+
+	return line
+}
+
+// unescapeMustaches turns the interpolations escaped in any style of the
+// dialect or wrapped in a literal block back into {{ }}
+func (d dialect) unescapeMustaches(line string) string {
+	if lit := d.literalMustacheRe(); lit != nil {
+		line = lit.ReplaceAllString(line, "$1")
+	}
+
+	for _, e := range d.escapes {
+		left := regexp.QuoteMeta(e.Left + e.Left)
+		right := regexp.QuoteMeta(e.Right + e.Right)
+
+		line = regexp.MustCompile(left+`([^$].*?)`+right).ReplaceAllStringFunc(line, func(m string) string {
+			return "{{" + m[len(e.Left)*2:len(m)-len(e.Right)*2] + "}}"
+		})
+	}
+
+	return line
+}
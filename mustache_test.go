@@ -0,0 +1,81 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+var mustacheLines = []string{
+	`<li>{{ item.name }} - {{item.price|currency}}</li>`,
+	`template: '<p>{{ msg }}</p>'`,
+	`<p>{$title} {{$notMustache}}</p>`,
+}
+
+var expMustacheInline = []string{
+	`<li>{ldelim}{ldelim} item.name {rdelim}{rdelim} - {ldelim}{ldelim}item.price|currency{rdelim}{rdelim}</li>`,
+	`template: '<p>{ldelim}{ldelim} msg {rdelim}{rdelim}</p>'`,
+	`<p>{$title} {{$notMustache}}</p>`,
+}
+
+var expMustacheLiteral = []string{
+	`<li>{literal}{{ item.name }}{/literal} - {literal}{{item.price|currency}}{/literal}</li>`,
+	`template: '<p>{literal}{{ msg }}{/literal}</p>'`,
+	`<p>{$title} {{$notMustache}}</p>`,
+}
+
+func TestMaskMustaches(t *testing.T) {
+	d := dialects["smarty2"]
+
+	for i, line := range mustacheLines {
+		masked, fragments := d.maskMustaches(line, "inline", "")
+
+		if r := restoreMustaches(masked, fragments); r != expMustacheInline[i] {
+			t.Fatalf("Expected inline escape: %s; got: %s", expMustacheInline[i], r)
+		}
+
+		masked, fragments = d.maskMustaches(line, "literal", "")
+
+		if r := restoreMustaches(masked, fragments); r != expMustacheLiteral[i] {
+			t.Fatalf("Expected literal escape: %s; got: %s", expMustacheLiteral[i], r)
+		}
+	}
+}
+
+func TestMaskMustachesWrapped(t *testing.T) {
+	d := dialects["smarty2"]
+	line := `<li>{literal}{{ item.name }}{/literal} - {{ item.price }}</li>`
+	exp := `<li>{literal}{{ item.name }}{/literal} - {literal}{{ item.price }}{/literal}</li>`
+
+	masked, fragments := d.maskMustaches(line, "literal", "")
+
+	if r := restoreMustaches(masked, fragments); r != exp {
+		t.Fatalf("Expected literal escape: %s; got: %s", exp, r)
+	}
+}
+
+func TestUnescapeMustaches(t *testing.T) {
+	d := dialects["smarty2"]
+
+	for i, line := range mustacheLines {
+		if r := d.unescapeMustaches(expMustacheInline[i]); r != line {
+			t.Fatalf("Expected inline unescape: %s; got: %s", line, r)
+		}
+
+		if r := d.unescapeMustaches(expMustacheLiteral[i]); r != line {
+			t.Fatalf("Expected literal unescape: %s; got: %s", line, r)
+		}
+	}
+
+	line := `<b>{$smarty.ldelim}{$smarty.ldelim} a {$smarty.rdelim}{$smarty.rdelim}</b>`
+
+	if r := d.unescapeMustaches(line); r != `<b>{{ a }}</b>` {
+		t.Fatalf("Expected smarty style unescape; got: %s", r)
+	}
+
+	line = `<b>{syntax off}{{ a }}{/syntax} {l}{l} b {r}{r}</b>`
+
+	if r := dialects["latte"].unescapeMustaches(line); r != `<b>{{ a }} {{ b }}</b>` {
+		t.Fatalf("Expected latte unescape; got: %s", r)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// styleCounts tallies the escapes replaced in the script regions of a
+// template while it is normalized
+type styleCounts struct {
+	dialect dialect
+
+	// style is the escape style normalized to, its tags are left in place
+	style string
+
+	// tags are the escape tags replaced by style, found those outside
+	// strings which are undone and made again along with the bare braces
+	tags  map[string]int
+	found int
+
+	literal       int
+	literalBraces int
+	escaped       int
+}
+
+func newStyleCounts(d dialect, style string) *styleCounts {
+	return &styleCounts{dialect: d, style: style, tags: map[string]int{}}
+}
+
+// addTags counts the escape tags of line by style
+func (c *styleCounts) addTags(line string) {
+	replace := func(text string) {
+		if style := c.dialect.escapeStyleOf(text); style != c.style {
+			c.tags[style]++
+		}
+	}
+
+	c.dialect.mapQuotedDelims(line, func(token delimToken, text, rest string) string {
+		if token == leftDelim || token == rightDelim {
+			c.found++
+			replace(text)
+		}
+
+		return text
+	}, func(quote byte, token delimToken, text string) string {
+		replace(text)
+
+		return text
+	})
+}
+
+// addLiteral counts an unwrapped {literal} block along with its braces
+func (c *styleCounts) addLiteral(content []string) {
+	c.literal++
+
+	for _, l := range content {
+		c.literalBraces += strings.Count(l, "{") + strings.Count(l, "}")
+	}
+}
+
+// bare is the amount of braces that were neither escaped nor in a {literal}
+// block, but had to be escaped to match the chosen convention
+func (c *styleCounts) bare() int {
+	bare := c.escaped - c.literalBraces - c.found
+
+	if bare < 0 {
+		return 0
+	}
+
+	return bare
+}
+
+// String lists the count of every escape style of the dialect replaced
+// followed by the {literal} blocks and bare braces
+func (c *styleCounts) String() string {
+	var counts []string
+	seen := map[string]bool{c.style: true}
+
+	for _, e := range c.dialect.escapes {
+		if !seen[e.Style] {
+			seen[e.Style] = true
+			counts = append(counts, fmt.Sprintf("%s: %d", e.Style, c.tags[e.Style]))
+		}
+	}
+
+	counts = append(counts, fmt.Sprintf("literal: %d", c.literal), fmt.Sprintf("bare: %d", c.bare()))
+
+	return strings.Join(counts, ", ")
+}
+
+// ----------------------- NORMALIZE
+
+// normalize rewrites every script region of the template to the convention
+// chosen by opts, whatever mix of escape tags, {literal} blocks and bare
+// braces it had. Every escape is undone first then made again, the escape
+// tags of strings being restyled, while {literal} blocks holding Smarty
+// syntax are kept
+func normalize(inputFile io.Reader, outputFile io.Writer, opts options) (*styleCounts, error) {
+	var bare bytes.Buffer
+
+	if opts.style == "" {
+		tags, _ := opts.dialect.escapeStyle("")
+		opts.style = tags.Style
+	}
+
+	counts := newStyleCounts(opts.dialect, opts.style)
+
+	delimOpts := opts
+	delimOpts.dialect.autoLiteral = false
+	delimOpts.unwrapLiteral = true
+	delimOpts.counts = counts
+
+	if err := parseDelims(inputFile, &bare, delimOpts); err != nil {
+		return counts, err
+	}
+
+	braceOpts := opts
+	braceOpts.counts = counts
+
+	if err := parseBraces(&bare, outputFile, braceOpts); err != nil {
+		return counts, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	inputFile, err := os.Open("files/mixed_styles.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inputFile.Close()
+
+	var output bytes.Buffer
+
+	counts, err := normalize(inputFile, &output, defaultOptions())
+	if err != nil {
+		t.Fatalf("Error during normalize: %s", err)
+	}
+
+	exp, err := ioutil.ReadFile("files/mixed_styles_normalized.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != string(exp) {
+		t.Fatalf("Expected normalized output:\n%s\ngot:\n%s", exp, output.String())
+	}
+
+	expCounts := "smarty: 1, quote: 2, literal: 1, bare: 2"
+
+	if counts.String() != expCounts {
+		t.Fatalf("Expected counts: %s; got: %s", expCounts, counts)
+	}
+}
+
+func TestNormalizeIdempotent(t *testing.T) {
+	inputFile, err := os.Open("files/mixed_styles_normalized.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inputFile.Close()
+
+	var output bytes.Buffer
+
+	counts, err := normalize(inputFile, &output, defaultOptions())
+	if err != nil {
+		t.Fatalf("Error during normalize: %s", err)
+	}
+
+	exp, err := ioutil.ReadFile("files/mixed_styles_normalized.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != string(exp) {
+		t.Fatalf("Expected normalized output to be unchanged:\n%s", output.String())
+	}
+
+	if counts.String() != "smarty: 0, quote: 0, literal: 0, bare: 0" {
+		t.Fatalf("Expected nothing replaced; got: %s", counts)
+	}
+}
+
+func TestNormalizeSmartyStyle(t *testing.T) {
+	inputFile, err := os.Open("files/mixed_styles.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inputFile.Close()
+
+	var output bytes.Buffer
+
+	opts := defaultOptions()
+	opts.style = "smarty"
+
+	counts, err := normalize(inputFile, &output, opts)
+	if err != nil {
+		t.Fatalf("Error during normalize: %s", err)
+	}
+
+	exp, err := ioutil.ReadFile("files/mixed_styles_smarty.tpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != string(exp) {
+		t.Fatalf("Expected normalized output:\n%s\ngot:\n%s", exp, output.String())
+	}
+
+	expCounts := "delim: 3, quote: 2, literal: 1, bare: 2"
+
+	if counts.String() != expCounts {
+		t.Fatalf("Expected counts: %s; got: %s", expCounts, counts)
+	}
+}
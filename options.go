@@ -0,0 +1,135 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// options tune how parseBraces and parseDelims convert a template
+type options struct {
+	dialect dialect
+
+	// strategy is how parseBraces escapes script braces, "inline" with
+	// {ldelim} and {rdelim} or "literal" wrapping pure script runs in a
+	// {literal} block once they need literalThreshold escapes
+	strategy         string
+	literalThreshold int
+
+	// unwrapLiteral makes parseDelims drop every {literal} wrapper within
+	// scripts, otherwise they are only dropped when an autoLiteral dialect
+	// renders their content the same
+	unwrapLiteral bool
+
+	// style names the dialect escape tags parseBraces emits, the first
+	// ones of the dialect when empty
+	style string
+
+	// mustache when set escapes {{ }} interpolations anywhere in the
+	// template, "inline" with the escape tags or "literal" in a literal block
+	mustache string
+
+	// policies tell what the parses do within strings, template literals,
+	// comments and regex literals
+	policies map[segmentKind]policy
+
+	// counts when set tallies the escape conventions met by the parsers
+	counts *styleCounts
+}
+
+func defaultOptions() options {
+	return options{
+		dialect:          dialects["smarty2"],
+		strategy:         "inline",
+		literalThreshold: 4,
+		policies:         defaultPolicies(),
+	}
+}
+
+// optionsFromArgs builds the options from the command line arguments, when
+// no dialect is given it is detected from the project owning the input file
+func optionsFromArgs(args map[string]interface{}) (options, error) {
+	opts := defaultOptions()
+	name := args["dialect"].(string)
+
+	if path := args["dialectFile"].(string); path != "" {
+		d, err := loadDialect(path)
+		if err != nil {
+			return opts, err
+		}
+
+		// the defined dialect is used unless another one is named
+		if name == "" || name == d.name {
+			opts.dialect, name = d, ""
+		}
+	}
+
+	if name == "" && args["dialectFile"].(string) == "" {
+		dir := args["inputPath"].(string)
+
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+
+		name, _ = detectDialect(dir)
+	}
+
+	if name != "" {
+		d, err := getDialect(name)
+		if err != nil {
+			return opts, err
+		}
+
+		opts.dialect = d
+	}
+
+	if strategy := args["strategy"].(string); strategy != "" {
+		if strategy != "inline" && strategy != "literal" {
+			return opts, fmt.Errorf("Unknown strategy: %s", strategy)
+		}
+
+		opts.strategy = strategy
+	}
+
+	if threshold := args["literalThreshold"].(int); threshold > 0 {
+		opts.literalThreshold = threshold
+	}
+
+	opts.unwrapLiteral = args["unwrap"].(bool)
+
+	if style := args["style"].(string); style != "" {
+		if _, ok := opts.dialect.escapeStyle(style); !ok {
+			return opts, fmt.Errorf("Unknown escape style for %s: %s", opts.dialect.name, style)
+		}
+
+		opts.style = style
+	}
+
+	if mustache := args["mustache"].(string); mustache != "" {
+		if mustache != "inline" && mustache != "literal" {
+			return opts, fmt.Errorf("Unknown mustache form: %s", mustache)
+		}
+
+		opts.mustache = mustache
+	}
+
+	if plugins := args["plugins"].(string); plugins != "" {
+		d, err := opts.dialect.withPlugins(strings.Split(plugins, ","))
+		if err != nil {
+			return opts, err
+		}
+
+		opts.dialect = d
+	}
+
+	if err := parsePolicies(args["policy"].(string), opts.policies); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
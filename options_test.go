@@ -0,0 +1,186 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestOptionsFromArgs(t *testing.T) {
+	args := getCommonFlags()
+	args["dialect"] = "smarty3"
+
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		t.Fatalf("Expected error to be nil; got: %s", err)
+	}
+
+	if !opts.dialect.autoLiteral {
+		t.Fatal("Expected smarty3 dialect to be auto literal")
+	}
+}
+
+func TestOptionsFromArgsDefault(t *testing.T) {
+	opts, err := optionsFromArgs(getCommonFlags())
+	if err != nil {
+		t.Fatalf("Expected error to be nil; got: %s", err)
+	}
+
+	if opts.dialect.name != "smarty2" {
+		t.Fatalf("Expected dialect: smarty2; got: %s", opts.dialect.name)
+	}
+}
+
+func TestOptionsFromArgsUnknownDialect(t *testing.T) {
+	args := getCommonFlags()
+	args["dialect"] = "twig"
+
+	if _, err := optionsFromArgs(args); err == nil {
+		t.Fatal("Expected error on unknown dialect")
+	}
+}
+
+func TestOptionsFromArgsDetectDialect(t *testing.T) {
+	args := getCommonFlags()
+	args["inputPath"] = "files/detect/json/index.tpl"
+
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		t.Fatalf("Expected error to be nil; got: %s", err)
+	}
+
+	if opts.dialect.name != "smarty3" {
+		t.Fatalf("Expected detected dialect: smarty3; got: %s", opts.dialect.name)
+	}
+}
+
+func TestOptionsFromArgsStrategy(t *testing.T) {
+	args := getCommonFlags()
+	args["strategy"] = "literal"
+	args["literalThreshold"] = 10
+
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		t.Fatalf("Expected error to be nil; got: %s", err)
+	}
+
+	if opts.strategy != "literal" || opts.literalThreshold != 10 {
+		t.Fatalf("Expected literal strategy with threshold 10; got: %s %d", opts.strategy, opts.literalThreshold)
+	}
+
+	args["strategy"] = "wrap"
+
+	if _, err := optionsFromArgs(args); err == nil {
+		t.Fatal("Expected error on unknown strategy")
+	}
+}
+
+func TestOptionsFromArgsStyle(t *testing.T) {
+	args := getCommonFlags()
+	args["style"] = "smarty"
+
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		t.Fatalf("Expected error to be nil; got: %s", err)
+	}
+
+	if opts.style != "smarty" {
+		t.Fatalf("Expected style: smarty; got: %s", opts.style)
+	}
+
+	args["style"] = "latte"
+
+	if _, err := optionsFromArgs(args); err == nil {
+		t.Fatal("Expected error on unknown style")
+	}
+}
+
+func TestOptionsFromArgsDialectFile(t *testing.T) {
+	args := getCommonFlags()
+	args["dialectFile"] = "files/dialects/fenom.json"
+
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		t.Fatalf("Expected error to be nil; got: %s", err)
+	}
+
+	if opts.dialect.name != "fenom" {
+		t.Fatalf("Expected dialect: fenom; got: %s", opts.dialect.name)
+	}
+
+	args["dialect"] = "fenom"
+
+	if opts, err = optionsFromArgs(args); err != nil || opts.dialect.name != "fenom" {
+		t.Fatalf("Expected the named dialect to be the defined one; got: %s %v", opts.dialect.name, err)
+	}
+
+	args["style"] = "delim"
+
+	if _, err = optionsFromArgs(args); err == nil {
+		t.Fatal("Expected error on style unknown to the dialect")
+	}
+}
+
+func TestOptionsFromArgsPolicy(t *testing.T) {
+	args := getCommonFlags()
+	args["policy"] = "line=convert"
+
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.policies[lineCommentSegment] != convertPolicy || opts.policies[stringSegment] != escapePolicy {
+		t.Fatalf("Expected line converted and string escaped; got: %v", opts.policies)
+	}
+
+	args["policy"] = "line=maybe"
+
+	if _, err := optionsFromArgs(args); err == nil {
+		t.Fatal("Expected error on unknown policy")
+	}
+}
+
+func TestOptionsFromArgsPlugins(t *testing.T) {
+	args := getCommonFlags()
+	args["plugins"] = "files/plugins,gauge"
+
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !opts.dialect.isSmartyTagStart(`{gauge value=$v}`) {
+		t.Fatal("Expected the gauge plugin to be registered")
+	}
+
+	if dialects["smarty2"].isSmartyTagStart(`{gauge value=$v}`) {
+		t.Fatal("Should not register the plugin globally")
+	}
+
+	args["plugins"] = "gauge()"
+
+	if _, err = optionsFromArgs(args); err == nil {
+		t.Fatal("Expected error on invalid plugin name")
+	}
+}
+
+func TestOptionsFromArgsMustache(t *testing.T) {
+	args := getCommonFlags()
+	args["mustache"] = "literal"
+
+	opts, err := optionsFromArgs(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.mustache != "literal" {
+		t.Fatalf("Expected mustache: literal; got: %s", opts.mustache)
+	}
+
+	args["mustache"] = "raw"
+
+	if _, err := optionsFromArgs(args); err == nil {
+		t.Fatal("Expected error on unknown mustache form")
+	}
+}
@@ -12,39 +12,40 @@ import (
 )
 
 // ----------------------- BRACES
-func parseBraces(inputFile io.Reader, outputFile io.Writer) error {
+func parseBraces(inputFile io.Reader, outputFile io.Writer, opts options) error {
 	reader := bufio.NewReaderSize(inputFile, 1024)
 	writer := bufio.NewWriterSize(outputFile, 1024)
 
 	var insideScriptTag bool
 	var insideLiteralTag bool
 	var insidePHPTag bool
+	var insideHTMLComment bool
+	var insideSmartyComment bool
 	var insideMultilineComment bool
+	var directives directiveState
 	var cm []string
 	var mlm []string
+	var templates []string
+	var mustaches []string
+	var php []string
+	var split bool
 
-	parse := func(line string, lf bool) string {
-		if line == "" {
-			return line
-		}
-
-		var anyMatched bool
-		var matched bool
-
-		line, matched = parseInlineObject(line)
-		anyMatched = anyMatched || matched
+	masker := newTemplateMasker(true, opts)
 
-		line, matched = parseLeftBrace(line)
-		anyMatched = anyMatched || matched
+	wrapper := &literalWrapper{
+		writer:    writer,
+		threshold: opts.literalThreshold,
+		dialect:   opts.dialect,
+	}
 
-		line, matched = parseRightBrace(line)
-		anyMatched = anyMatched || matched
+	emit := func(raw, parsed string) {
+		parsed = restorePHP(restoreMustaches(parsed, mustaches), php)
 
-		if anyMatched && lf {
-			line += "\n"
+		if opts.strategy == "literal" {
+			wrapper.add(raw, parsed, split)
+		} else {
+			writer.WriteString(parsed)
 		}
-
-		return line
 	}
 
 	assembleFragments := func(l string, fragmets []string) string {
@@ -52,7 +53,7 @@ func parseBraces(inputFile io.Reader, outputFile io.Writer) error {
 			l = strings.Replace(l, "[FCT-"+strconv.Itoa(i)+"]", v, 1)
 		}
 
-		return l
+		return restoreTemplates(l, templates)
 	}
 
 	for {
@@ -69,15 +70,63 @@ func parseBraces(inputFile io.Reader, outputFile io.Writer) error {
 			return err
 		}
 
+		raw := line
+		mustaches = nil
+		split = false
+
+		if directives.ignores(line) {
+			insideScriptTag = scriptStateAfter(line, insideScriptTag)
+			wrapper.write(line)
+			continue
+		}
+
+		if insidePHPTag && !endOfPHPTag(line) {
+			php = nil
+
+			if insideScriptTag {
+				emit(raw, raw)
+			} else {
+				wrapper.write(raw)
+			}
+
+			continue
+		}
+
+		// the text around PHP regions is parsed, the one closing a region
+		// opened on a previous line or opening one going on past it included
+		line, php, insidePHPTag = maskPHPLine(line, insidePHPTag)
+
+		if opts.mustache != "" && !insideLiteralTag {
+			line, mustaches = opts.dialect.maskMustaches(line, opts.mustache, opts.style)
+		}
+
 		if !insideScriptTag {
-			insideScriptTag = startOfScriptTag(line)
+			var html string
+
+			// a script tag within an html or Smarty comment opens no script
+			html, insideSmartyComment = stripSmartyComments(line, insideSmartyComment)
+			html, insideHTMLComment = stripHTMLComments(html, insideHTMLComment)
+			insideScriptTag = startOfScriptTag(html)
+			insideHTMLComment = insideHTMLComment && !insideScriptTag
+			insideSmartyComment = insideSmartyComment && !insideScriptTag
 		}
 
 		if !insideScriptTag {
-			writer.WriteString(line)
+			wrapper.write(restorePHP(restoreMustaches(line, mustaches), php))
 			continue
 		}
 
+		templates = nil
+		split = masker.scanner.continues()
+
+		// the scanner follows literal blocks as well so its state is right
+		// once they end
+		if masked, fragments := masker.mask(line); !insideLiteralTag {
+			line, templates = masked, fragments
+		}
+
+		split = split || masker.scanner.continues()
+
 		line, fragments := parseCommentFragmets(line)
 
 		if !insideMultilineComment {
@@ -108,7 +157,7 @@ func parseBraces(inputFile io.Reader, outputFile io.Writer) error {
 				leftComment = mlm[0]
 				line = mlm[1] + "\n"
 			} else {
-				writer.WriteString(line)
+				emit(raw, assembleFragments(line, fragments))
 				continue
 			}
 		}
@@ -122,45 +171,40 @@ func parseBraces(inputFile io.Reader, outputFile io.Writer) error {
 			}
 		}
 
-		if !insidePHPTag {
-			insidePHPTag = startOfPHPTag(line)
-		}
-
-		if insidePHPTag {
-			insidePHPTag = !endOfPHPTag(line)
-			writer.WriteString(assembleFragments(leftComment+line+comment+rightComment, fragments))
-			continue
-		}
-
 		if !insideLiteralTag {
-			insideLiteralTag = startOfLiteralTag(line)
+			insideLiteralTag = opts.dialect.startOfLiteralTag(line)
 		}
 
 		if insideLiteralTag {
-			insideLiteralTag = !endOfLiteralTag(line)
-			writer.WriteString(assembleFragments(leftComment+line+comment+rightComment, fragments))
+			insideLiteralTag = !opts.dialect.endOfLiteralTag(line)
+			emit(raw, assembleFragments(leftComment+line+comment+rightComment, fragments))
 			continue
 		}
 
-		if isRegExp(line) {
-			slice, _ := parseRegExp(line)
+		_, escapes := opts.dialect.stripEscapeTags(line)
+		line = opts.dialect.canonicalDelims(line)
 
-			line = parse(slice[0], false) + slice[1] + parse(slice[2], false)
+		line = opts.dialect.escapeBraces(line)
 
-			if !isCommentLine && !firstML {
-				line += "\n"
-			}
-		} else {
-			line = parse(line, !isCommentLine && !firstML)
+		if opts.dialect.autoLiteral {
+			line = opts.dialect.outsideInlineLiterals(line, autoLiteralDelims)
+		}
+
+		line = opts.dialect.restyleDelims(line, opts.style)
+
+		if opts.counts != nil {
+			_, n := opts.dialect.stripEscapeTags(line)
+			opts.counts.escaped += n - escapes
 		}
 
 		if insideScriptTag {
-			insideScriptTag = !endOfScriptTag(line)
+			insideScriptTag = !endOfScriptTag(line + comment)
 		}
 
-		writer.WriteString(assembleFragments(leftComment+line+comment+rightComment, fragments))
+		emit(raw, assembleFragments(leftComment+line+comment+rightComment, fragments))
 	}
 
+	wrapper.flush()
 	writer.Flush()
 
 	return nil
@@ -6,9 +6,16 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"io"
+	"io/ioutil"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/quick"
 )
 
 func TestParseFileBrace(t *testing.T) {
@@ -28,7 +35,7 @@ func TestParseFileBrace(t *testing.T) {
 		t.Fatalf("Error opening output file: %s", err)
 	}
 
-	err = parseBraces(inputFile, outPutFile)
+	err = parseBraces(inputFile, outPutFile, defaultOptions())
 
 	if err != nil {
 		t.Fatalf("Error during parse: %s", err)
@@ -87,3 +94,400 @@ func TestParseFileBrace(t *testing.T) {
 		t.Fatal("Expected file and output file did not match")
 	}
 }
+
+func testParseFile(t *testing.T, parse func(io.Reader, io.Writer, options) error, opts options, input, exp string) {
+	inputFile, err := os.Open(input)
+	if err != nil {
+		t.Fatalf("Error opening input file: %s", err)
+	}
+	defer inputFile.Close()
+
+	var output bytes.Buffer
+
+	if err = parse(inputFile, &output, opts); err != nil {
+		t.Fatalf("Error during parse: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outLines := strings.SplitAfter(output.String(), "\n")
+	expLines := strings.SplitAfter(string(expected), "\n")
+
+	for i, expLine := range expLines {
+		if i >= len(outLines) {
+			t.Fatalf("Expected line %d: %s; got end of output", i+1, expLine)
+		}
+
+		if outLines[i] != expLine {
+			t.Fatalf("Lines %d does not match \n%s\n%s", i+1, outLines[i], expLine)
+		}
+	}
+
+	if len(outLines) != len(expLines) {
+		t.Fatalf("Expected %d lines; got: %d", len(expLines), len(outLines))
+	}
+}
+
+func TestParseFileBraceAutoLiteral(t *testing.T) {
+	opts := defaultOptions()
+	opts.dialect = dialects["smarty3"]
+
+	testParseFile(t, parseBraces, opts, "files/simple_brace.tpl", "files/simple_delim_smarty3.tpl")
+}
+
+func TestParseFileBraceLiteral(t *testing.T) {
+	opts := defaultOptions()
+	opts.strategy = "literal"
+
+	testParseFile(t, parseBraces, opts, "files/simple_brace.tpl", "files/simple_delim_literal.tpl")
+
+	// lines going on within a template literal or a string are never wrapped
+	opts.literalThreshold = 1
+
+	testParseFile(t, parseBraces, opts, "files/multiline_brace.tpl", "files/multiline_delim_literal.tpl")
+}
+
+func TestParseFileBraceQuoteStyle(t *testing.T) {
+	opts := defaultOptions()
+	opts.style = "quote"
+
+	testParseFile(t, parseBraces, opts, "files/simple_brace.tpl", "files/simple_delim_quote.tpl")
+}
+
+func TestParseFileBraceLatte(t *testing.T) {
+	opts := defaultOptions()
+	opts.dialect = dialects["latte"]
+
+	testParseFile(t, parseBraces, opts, "files/latte_brace.latte", "files/latte_delim.latte")
+}
+
+func TestParseFileBraceTemplateLiterals(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/template_brace.tpl", "files/template_delim.tpl")
+}
+
+func TestParseFileBraceRegExps(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/regex_brace.tpl", "files/regex_delim.tpl")
+}
+
+func TestParseFileBraceStringComments(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/comment_brace.tpl", "files/comment_delim.tpl")
+}
+
+func TestParseFileBracePolicies(t *testing.T) {
+	opts := defaultOptions()
+	parsePolicies("string=convert,template=convert,line=convert,block=convert,regex=convert", opts.policies)
+
+	testParseFile(t, parseBraces, opts, "files/policy_brace.tpl", "files/policy_delim.tpl")
+}
+
+func TestParseFileBraceSmartyTags(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/smarty_brace.tpl", "files/smarty_delim.tpl")
+}
+
+func TestParseFileBraceVocabulary(t *testing.T) {
+	opts := defaultOptions()
+	d, err := opts.dialect.withPlugins([]string{"files/plugins"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts.dialect = d
+
+	testParseFile(t, parseBraces, opts, "files/vocabulary_brace.tpl", "files/vocabulary_delim.tpl")
+}
+
+func TestParseFileBraceModifiers(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/modifiers_brace.tpl", "files/modifiers_delim.tpl")
+}
+
+func TestParseFileBraceES2020(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/es2020_brace.tpl", "files/es2020_delim.tpl")
+
+	opts := defaultOptions()
+	opts.dialect = dialects["smarty3"]
+
+	testParseFile(t, parseBraces, opts, "files/es2020_brace.tpl", "files/es2020_delim_smarty3.tpl")
+}
+
+func TestParseFileBraceMustache(t *testing.T) {
+	opts := defaultOptions()
+	opts.mustache = "inline"
+
+	testParseFile(t, parseBraces, opts, "files/vue_brace.tpl", "files/vue_delim.tpl")
+
+	opts.mustache = "literal"
+
+	testParseFile(t, parseBraces, opts, "files/vue_brace.tpl", "files/vue_delim_literal.tpl")
+}
+
+func TestParseFileBracePHP(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/php_brace.tpl", "files/php_delim.tpl")
+}
+
+func TestParseFileBraceLegacyWrappers(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/legacy_brace.tpl", "files/legacy_delim.tpl")
+}
+
+func TestParseFileBraceSmartyComments(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/smarty_comment_brace.tpl", "files/smarty_comment_delim.tpl")
+}
+
+func TestParseFileBraceDirectives(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/directive_brace.tpl", "files/directive_delim.tpl")
+}
+
+// ------------ IDEMPOTENCE
+
+// templateSnippets are the lines random templates are made of, mixing JS,
+// Smarty syntax, escapes and the contexts the parses take care of
+var templateSnippets = []string{
+	"<p>{$title}</p>",
+	"<div>{* note *}</div>",
+	"var a = {b: 1};",
+	"var a = {ldelim}b: 1{rdelim};",
+	"function c() { return {d: {$e}}; }",
+	"if (f) { g({h: [1, 2]}) }",
+	"}",
+	"{",
+	"var i = '{j}' + \"{$k}\";",
+	"var l = `m ${n} {o}`;",
+	"var p = `",
+	"  {q} ${r.map(s => `${s}`)}",
+	"`;",
+	"// { comment {ldelim}",
+	"/* block { */ var t = {};",
+	"/**",
+	" * { doc",
+	" */",
+	"var u = /[{}]+/g.test(v);",
+	"{if $w}x = {y: 1};{/if}",
+	"{foreach $z as $item}",
+	"{/foreach}",
+	"{literal}",
+	"var aa = {bb: 1};",
+	"{/literal}",
+	"{include file=\"cc.tpl\"",
+	"  assign=dd}",
+	"var ee = <?= json_encode($ff) ?>, gg = {};",
+	"<!--",
+	"//-->",
+	"{* smarty-brace: off *}",
+	"{* smarty-brace: on *}",
+	"// smarty-brace-ignore-next-line",
+	"var hh = {$ii|default:'{}'};",
+	"var jj = {literal}{kk: 1}{/literal}; var ll = {};",
+	"{literal}{mm: {nn: 2}}{/literal} oo({pp: 3})",
+}
+
+// convertibleUnits are well formed pieces of script, possibly spanning
+// lines, whose code braces outside literal blocks are all to be converted
+var convertibleUnits = []string{
+	"var a = {b: 1};",
+	"var a = {ldelim}b: 1{rdelim};",
+	"function c() { return {d: {$e}}; }",
+	"if (f) { g({h: [1, 2]}) }",
+	"var i = '{j}' + \"{$k}\";",
+	"var l = `m ${n} {o}`;",
+	"var p = `\n  {q} ${r.map(s => `${s}`)}\n`;",
+	"// { comment {ldelim}",
+	"/* block { */ var t = {};",
+	"/**\n * { doc\n */",
+	"var u = /[{}]+/g.test(v);",
+	"{if $w}x = {y: 1};{/if}",
+	"{foreach $z as $item}\nvar q = {r: $item};\n{/foreach}",
+	"{literal}\nvar aa = {bb: 1};\n{/literal}",
+	"{include file=\"cc.tpl\"\n  assign=dd}",
+	"var ee = <?= json_encode($ff) ?>, gg = {};",
+	"var hh = {$ii|default:'{}'};",
+	"var jj = {literal}{kk: 1}{/literal}; var ll = {};",
+	"{literal}{mm: {nn: 2}}{/literal} oo({pp: 3})",
+	"<!--\nvar qq = {rr: 1};\n//-->",
+}
+
+// randomTemplate is a template of random snippets, mostly within a script
+type randomTemplate string
+
+func (randomTemplate) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(randomTemplate(generateTemplate(r, size)))
+}
+
+// convertibleTemplate is a template of random convertibleUnits within a
+// script
+type convertibleTemplate string
+
+func (convertibleTemplate) Generate(r *rand.Rand, size int) reflect.Value {
+	units := make([]string, size)
+
+	for i := range units {
+		units[i] = convertibleUnits[r.Intn(len(convertibleUnits))]
+	}
+
+	return reflect.ValueOf(convertibleTemplate(wrapScript(units)))
+}
+
+func generateTemplate(r *rand.Rand, size int) string {
+	lines := make([]string, size)
+
+	for i := range lines {
+		lines[i] = templateSnippets[r.Intn(len(templateSnippets))]
+	}
+
+	return wrapScript(lines)
+}
+
+// wrapScript joins lines within a script following some markup
+func wrapScript(lines []string) string {
+	lines = append([]string{"<h1>{$title}</h1>", "<script type=\"text/javascript\">"}, lines...)
+
+	return strings.Join(append(lines, "</script>", ""), "\n")
+}
+
+// unconvertedCode returns the first code of the scripts of tpl, outside
+// literal blocks, holding a bare brace when brace or an escape tag
+// otherwise, empty when the parse converted all of them
+func unconvertedCode(tpl string, d dialect, brace bool) string {
+	var inScript, inLiteral bool
+
+	s := &jsScanner{dialect: d}
+
+	for _, line := range strings.Split(tpl, "\n") {
+		if !inScript {
+			inScript = startOfScriptTag(line)
+			continue
+		}
+
+		if endOfScriptTag(line) {
+			inScript = false
+			continue
+		}
+
+		for _, seg := range s.scan(line) {
+			for _, part := range d.splitLiteralTags(seg.text) {
+				if seg.kind == smartyTagSegment || seg.kind == templateSegment || seg.kind == codeSegment {
+					if part == d.literal[0] || part == d.literal[1] {
+						inLiteral = part == d.literal[0]
+						continue
+					}
+				}
+
+				if inLiteral || seg.kind != codeSegment {
+					continue
+				}
+
+				stripped, escapes := d.stripEscapeTags(part)
+
+				if brace && strings.ContainsAny(stripped, "{}") || !brace && escapes > 0 {
+					return line
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// idempotent tells whether running parse over its own output changes
+// nothing
+func idempotent(parse func(io.Reader, io.Writer, options) error, opts options, src string) bool {
+	var once, twice bytes.Buffer
+
+	if err := parse(strings.NewReader(src), &once, opts); err != nil {
+		return false
+	}
+
+	if err := parse(bytes.NewReader(once.Bytes()), &twice, opts); err != nil {
+		return false
+	}
+
+	return once.String() == twice.String()
+}
+
+// idempotenceOptions are the option sets idempotence is checked under
+func idempotenceOptions() map[string]options {
+	opts := map[string]options{"default": defaultOptions()}
+
+	smarty3 := defaultOptions()
+	smarty3.dialect = dialects["smarty3"]
+	opts["smarty3"] = smarty3
+
+	literal := defaultOptions()
+	literal.strategy = "literal"
+	opts["literal"] = literal
+
+	quote := defaultOptions()
+	quote.style = "quote"
+	opts["quote"] = quote
+
+	mustache := defaultOptions()
+	mustache.mustache = "literal"
+	opts["mustache"] = mustache
+
+	escape := defaultOptions()
+	parsePolicies("string=escape,template=escape,line=escape,block=escape,regex=escape", escape.policies)
+	opts["escape"] = escape
+
+	return opts
+}
+
+func testIdempotence(t *testing.T, parse func(io.Reader, io.Writer, options) error) {
+	files, _ := filepath.Glob("files/*.tpl")
+
+	for name, opts := range idempotenceOptions() {
+		for _, f := range files {
+			src, err := ioutil.ReadFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !idempotent(parse, opts, string(src)) {
+				t.Fatalf("Expected %s parse of %s to be idempotent", name, f)
+			}
+		}
+
+		property := func(tpl randomTemplate) bool {
+			return idempotent(parse, opts, string(tpl))
+		}
+
+		if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+			t.Fatalf("Expected %s parse to be idempotent: %s", name, err)
+		}
+	}
+}
+
+func TestParseBracesIdempotent(t *testing.T) {
+	testIdempotence(t, parseBraces)
+}
+
+func testConversion(t *testing.T, parse func(io.Reader, io.Writer, options) error, brace bool) {
+	opts := defaultOptions()
+
+	property := func(tpl convertibleTemplate) bool {
+		var output bytes.Buffer
+
+		if err := parse(strings.NewReader(string(tpl)), &output, opts); err != nil {
+			return false
+		}
+
+		if line := unconvertedCode(output.String(), opts.dialect, brace); line != "" {
+			t.Logf("Unconverted code: %s", line)
+			return false
+		}
+
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 50}); err != nil {
+		t.Fatalf("Expected every code brace to be converted: %s", err)
+	}
+}
+
+func TestParseBracesConvertsCode(t *testing.T) {
+	testConversion(t, parseBraces, true)
+}
+
+func TestParseFileBraceInlineLiteral(t *testing.T) {
+	testParseFile(t, parseBraces, defaultOptions(), "files/inline_literal_brace.tpl", "files/inline_literal_delim.tpl")
+}
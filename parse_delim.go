@@ -12,23 +12,43 @@ import (
 )
 
 // ----------------------- DELIMS
-func parseDelims(inputFile io.Reader, outputFile io.Writer) error {
+func parseDelims(inputFile io.Reader, outputFile io.Writer, opts options) error {
 	reader := bufio.NewReaderSize(inputFile, 1024)
 	writer := bufio.NewWriterSize(outputFile, 1024)
 
 	var insideScriptTag bool
 	var insideLiteralTag bool
 	var insidePHPTag bool
+	var insideHTMLComment bool
+	var insideSmartyComment bool
 	var insideMultilineComment bool
+	var directives directiveState
 	var cm []string
 	var mlm []string
+	var templates []string
+	var php []string
+
+	masker := newTemplateMasker(false, opts)
+	var literalBlock []string
+
+	unwrap := opts.unwrapLiteral || opts.dialect.autoLiteral
+
+	// write holds lines back while a literal block is waiting to be
+	// unwrapped, so they keep their place
+	write := func(l string) {
+		if len(literalBlock) > 0 {
+			literalBlock = append(literalBlock, l)
+		} else {
+			writer.WriteString(l)
+		}
+	}
 
 	assembleFragments := func(l string, fragmets []string) string {
 		for i, v := range fragmets {
 			l = strings.Replace(l, "[FCT-"+strconv.Itoa(i)+"]", v, 1)
 		}
 
-		return l
+		return restorePHP(restoreTemplates(l, templates), php)
 	}
 
 	for {
@@ -45,15 +65,49 @@ func parseDelims(inputFile io.Reader, outputFile io.Writer) error {
 			return err
 		}
 
+		if directives.ignores(line) {
+			insideScriptTag = scriptStateAfter(line, insideScriptTag)
+			write(line)
+			continue
+		}
+
+		if insidePHPTag && !endOfPHPTag(line) {
+			write(line)
+			continue
+		}
+
+		// the text around PHP regions is parsed, the one closing a region
+		// opened on a previous line or opening one going on past it included
+		line, php, insidePHPTag = maskPHPLine(line, insidePHPTag)
+
+		if opts.mustache != "" && !insideLiteralTag {
+			line = opts.dialect.unescapeMustaches(line)
+		}
+
 		if !insideScriptTag {
-			insideScriptTag = startOfScriptTag(line)
+			var html string
+
+			// a script tag within an html or Smarty comment opens no script
+			html, insideSmartyComment = stripSmartyComments(line, insideSmartyComment)
+			html, insideHTMLComment = stripHTMLComments(html, insideHTMLComment)
+			insideScriptTag = startOfScriptTag(html)
+			insideHTMLComment = insideHTMLComment && !insideScriptTag
+			insideSmartyComment = insideSmartyComment && !insideScriptTag
 		}
 
 		if !insideScriptTag {
-			writer.WriteString(line)
+			write(restorePHP(line, php))
 			continue
 		}
 
+		templates = nil
+
+		// the scanner follows literal blocks as well so its state is right
+		// once they end
+		if masked, fragments := masker.mask(line); !insideLiteralTag {
+			line, templates = masked, fragments
+		}
+
 		line, fragments := parseCommentFragmets(line)
 
 		if !insideMultilineComment {
@@ -84,7 +138,7 @@ func parseDelims(inputFile io.Reader, outputFile io.Writer) error {
 				leftComment = mlm[0]
 				line = mlm[1] + "\n"
 			} else {
-				writer.WriteString(line)
+				write(assembleFragments(line, fragments))
 				continue
 			}
 		}
@@ -96,41 +150,84 @@ func parseDelims(inputFile io.Reader, outputFile io.Writer) error {
 			comment = cm[1] + "\n"
 		}
 
-		if !insidePHPTag {
-			insidePHPTag = startOfPHPTag(line)
+		if !insideLiteralTag {
+			insideLiteralTag = opts.dialect.startOfLiteralTag(line)
 		}
 
-		if insidePHPTag {
-			insidePHPTag = !endOfPHPTag(line)
-			writer.WriteString(assembleFragments(leftComment+line+comment+rightComment, fragments))
+		if insideLiteralTag {
+			insideLiteralTag = !opts.dialect.endOfLiteralTag(line)
+			line = assembleFragments(leftComment+line+comment+rightComment, fragments)
+
+			if !unwrap {
+				writer.WriteString(line)
+				continue
+			}
+
+			literalBlock = append(literalBlock, line)
+
+			if !insideLiteralTag {
+				lines, unwrapped := opts.dialect.unwrapLiteralBlock(literalBlock, opts.unwrapLiteral)
+
+				if unwrapped && opts.counts != nil {
+					opts.counts.addLiteral(lines)
+				}
+
+				for _, l := range lines {
+					writer.WriteString(l)
+				}
+
+				literalBlock = nil
+			}
+
 			continue
 		}
 
-		if !insideLiteralTag {
-			insideLiteralTag = startOfLiteralTag(line)
+		if opts.counts != nil {
+			opts.counts.addTags(line)
 		}
 
-		if insideLiteralTag {
-			insideLiteralTag = !endOfLiteralTag(line)
-			writer.WriteString(assembleFragments(leftComment+line+comment+rightComment, fragments))
-			continue
-		}
+		line = opts.dialect.canonicalDelims(line)
+
+		if opts.dialect.autoLiteral {
+			line = opts.dialect.restyleDelims(opts.dialect.outsideInlineLiterals(line, autoLiteralDelims), opts.style)
+		} else {
+			lf := strings.HasSuffix(line, "\n")
 
-		line, _, split := parseLDelim(line)
-		if split {
-			line = line + "\n"
+			line = opts.dialect.outsideInlineLiterals(line, func(text string) string {
+				text, _, _ = parseLDelim(text)
+				text, _, _ = parseRDelim(text)
+
+				return text
+			})
+
+			// splitting around strings drops the line break, which only
+			// belongs here when no comment follows
+			if lf && !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
 		}
 
-		line, _, split = parseRDelim(line)
-		if split {
-			line = line + "\n"
+		if unwrap {
+			var contents []string
+
+			line, contents = opts.dialect.unwrapInlineLiterals(line, opts.unwrapLiteral)
+
+			if opts.counts != nil {
+				for _, c := range contents {
+					opts.counts.addLiteral([]string{c})
+				}
+			}
 		}
 
 		if insideScriptTag {
-			insideScriptTag = !endOfScriptTag(line)
+			insideScriptTag = !endOfScriptTag(line + comment)
 		}
 
-		writer.WriteString(assembleFragments(leftComment+line+comment+rightComment, fragments))
+		write(assembleFragments(leftComment+line+comment+rightComment, fragments))
+	}
+
+	for _, l := range literalBlock {
+		writer.WriteString(l)
 	}
 
 	writer.Flush()
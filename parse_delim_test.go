@@ -14,7 +14,8 @@ import (
 func TestParseFileDelim(t *testing.T) {
 	input := "files/simple_delim.tpl"
 	output := "files/simple_delim_parsed.tpl"
-	exp := "files/simple_brace.tpl"
+	// the "{lrdelim}" string the brace parse escaped stays escaped
+	exp := "files/simple_brace_roundtrip.tpl"
 
 	inputFile, err := os.Open(input)
 	defer inputFile.Close()
@@ -28,7 +29,7 @@ func TestParseFileDelim(t *testing.T) {
 		t.Fatalf("Error opening output file: %s", err)
 	}
 
-	err = parseDelims(inputFile, outPutFile)
+	err = parseDelims(inputFile, outPutFile, defaultOptions())
 
 	if err != nil {
 		t.Fatalf("Error during parse: %s", err)
@@ -87,3 +88,113 @@ func TestParseFileDelim(t *testing.T) {
 		t.Fatal("Expected file and output file did not match")
 	}
 }
+
+func TestParseFileDelimAutoLiteral(t *testing.T) {
+	opts := defaultOptions()
+	opts.dialect = dialects["smarty3"]
+
+	testParseFile(t, parseDelims, opts, "files/simple_delim.tpl", "files/simple_delim_smarty3.tpl")
+
+	// the text sharing a line with the tags is checked too
+	testParseFile(t, parseDelims, opts, "files/literal_edges_delim.tpl", "files/literal_edges_smarty3.tpl")
+}
+
+func TestParseFileDelimUnwrap(t *testing.T) {
+	opts := defaultOptions()
+	opts.unwrapLiteral = true
+
+	testParseFile(t, parseDelims, opts, "files/simple_delim_literal.tpl", "files/simple_brace_unwrap.tpl")
+}
+
+func TestParseFileDelimQuoteStyle(t *testing.T) {
+	// the quote style tags of strings are kept as any other string escape
+	testParseFile(t, parseDelims, defaultOptions(), "files/simple_delim_quote.tpl", "files/simple_brace_roundtrip_quote.tpl")
+}
+
+func TestParseFileDelimTemplateLiterals(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/template_delim.tpl", "files/template_brace.tpl")
+}
+
+func TestParseFileDelimRegExps(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/regex_delim.tpl", "files/regex_brace.tpl")
+}
+
+func TestParseFileDelimStringComments(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/comment_delim.tpl", "files/comment_brace.tpl")
+}
+
+func TestParseFileDelimEscapedQuotes(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/quotes_delim.tpl", "files/quotes_brace.tpl")
+}
+
+func TestParseFileDelimPolicies(t *testing.T) {
+	opts := defaultOptions()
+	parsePolicies("string=convert,template=convert,line=convert,block=convert,regex=convert", opts.policies)
+
+	testParseFile(t, parseDelims, opts, "files/policy_delim.tpl", "files/policy_brace.tpl")
+}
+
+func TestParseFileDelimSmartyTags(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/smarty_delim.tpl", "files/smarty_brace.tpl")
+}
+
+func TestParseFileDelimVocabulary(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/vocabulary_delim.tpl", "files/vocabulary_brace.tpl")
+}
+
+func TestParseFileDelimModifiers(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/modifiers_delim.tpl", "files/modifiers_brace.tpl")
+}
+
+func TestParseFileDelimES2020(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/es2020_delim.tpl", "files/es2020_brace.tpl")
+
+	opts := defaultOptions()
+	opts.dialect = dialects["smarty3"]
+
+	// the escapes smarty3 needs are all kept
+	testParseFile(t, parseDelims, opts, "files/es2020_delim_smarty3.tpl", "files/es2020_delim_smarty3.tpl")
+}
+
+func TestParseFileDelimMustache(t *testing.T) {
+	opts := defaultOptions()
+	opts.mustache = "inline"
+
+	testParseFile(t, parseDelims, opts, "files/vue_delim.tpl", "files/vue_brace.tpl")
+	testParseFile(t, parseDelims, opts, "files/vue_delim_literal.tpl", "files/vue_brace.tpl")
+}
+
+func TestParseFileDelimPHP(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/php_delim.tpl", "files/php_brace.tpl")
+}
+
+func TestParseFileDelimLegacyWrappers(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/legacy_delim.tpl", "files/legacy_brace.tpl")
+}
+
+func TestParseFileDelimSmartyComments(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/smarty_comment_delim.tpl", "files/smarty_comment_brace.tpl")
+}
+
+func TestParseFileDelimDirectives(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/directive_delim.tpl", "files/directive_brace.tpl")
+}
+
+func TestParseDelimsIdempotent(t *testing.T) {
+	testIdempotence(t, parseDelims)
+}
+
+func TestParseFileDelimInlineLiteral(t *testing.T) {
+	testParseFile(t, parseDelims, defaultOptions(), "files/inline_literal_delim.tpl", "files/inline_literal_brace.tpl")
+}
+
+func TestParseFileDelimInlineLiteralUnwrap(t *testing.T) {
+	opts := defaultOptions()
+	opts.unwrapLiteral = true
+
+	testParseFile(t, parseDelims, opts, "files/inline_literal_delim.tpl", "files/inline_literal_unwrap.tpl")
+}
+
+func TestParseDelimsConvertsCode(t *testing.T) {
+	testConversion(t, parseDelims, false)
+}
@@ -4,19 +4,114 @@
 
 package main
 
-import "regexp"
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
 
-// ------------ SCRIPT TAGS
+// ------------ PHP TAGS
+
+// phpRegionRe matches the Smarty {php} blocks and native <?php, <?= or <?
+// sections opened and closed within a line, not an <?xml declaration
+var phpRegionRe = regexp.MustCompile(`\{php\}.*?\{/php\}|<\?(?:php\b|=|\s).*?\?>`)
+
+var phpOpenRe = regexp.MustCompile(`\{php\}|<\?(?:php\b|=|\s|$)`)
+
+var phpCloseRe = regexp.MustCompile(`\{/php\}|\?>`)
+
+// xmlDeclRe matches the <?xml declarations, whose ?> closes no PHP region
+var xmlDeclRe = regexp.MustCompile(`<\?xml\b.*?\?>`)
+
+// startOfPHPTag tells whether line opens a PHP region going on past it
 func startOfPHPTag(line string) bool {
-	re := `\{php\}(.+(\{\/php\}))?`
-	match := regexp.MustCompile(re).FindStringSubmatch(line)
+	line, _ = maskPHP(line)
 
-	return match != nil && len(match) == 3 && match[2] != "</php>"
+	return phpOpenRe.MatchString(line)
 }
 
+// endOfPHPTag tells whether line closes a PHP region opened on a previous
+// line
 func endOfPHPTag(line string) bool {
-	re := `((\{php).+)?\{\/php\}`
-	match := regexp.MustCompile(re).FindStringSubmatch(line)
+	return closeOfPHPTag(line) >= 0
+}
+
+// closeOfPHPTag returns the index after the tag of line closing a PHP region
+// opened on a previous line, or -1 when the region goes on past it. The
+// regions opened and closed within line and the ?> of <?xml declarations
+// close none
+func closeOfPHPTag(line string) int {
+	masked, fragments := maskPHP(line)
+	decls := xmlDeclRe.FindAllStringIndex(masked, -1)
+
+	for _, loc := range phpCloseRe.FindAllStringIndex(masked, -1) {
+		inDecl := false
+
+		for _, d := range decls {
+			inDecl = inDecl || loc[0] >= d[0] && loc[1] <= d[1]
+		}
+
+		if !inDecl {
+			return len(restorePHP(masked[:loc[1]], fragments))
+		}
+	}
+
+	return -1
+}
+
+// maskPHP replaces the PHP regions opened and closed within line by [PHP-n]
+// fragments so neither parse alters them
+func maskPHP(line string) (string, []string) {
+	return appendPHP(line, nil)
+}
+
+func appendPHP(line string, fragments []string) (string, []string) {
+	line = phpRegionRe.ReplaceAllStringFunc(line, func(m string) string {
+		fragments = append(fragments, m)
+
+		return "[PHP-" + strconv.Itoa(len(fragments)-1) + "]"
+	})
+
+	return line, fragments
+}
+
+// maskPHPLine masks every PHP region of a line so the text around them is
+// parsed as any other: when inside, the end of the region opened on a
+// previous line up to its closing tag, then the regions opened and closed
+// within line and last the one it leaves going on past it, from its opening
+// tag to the line break. It tells whether a region is left open
+func maskPHPLine(line string, inside bool) (string, []string, bool) {
+	var head string
+	var fragments []string
+
+	if inside {
+		end := closeOfPHPTag(line)
+		if end < 0 {
+			return "[PHP-0]", []string{line}, true
+		}
+
+		head, fragments, line = "[PHP-0]", []string{line[:end]}, line[end:]
+	}
+
+	line, fragments = appendPHP(line, fragments)
+
+	loc := phpOpenRe.FindStringIndex(line)
+	if loc == nil {
+		return head + line, fragments, false
+	}
+
+	body := strings.TrimSuffix(line, "\n")
+	fragments = append(fragments, body[loc[0]:])
+	line = body[:loc[0]] + "[PHP-" + strconv.Itoa(len(fragments)-1) + "]" + line[len(body):]
+
+	return head + line, fragments, true
+}
+
+// restorePHP puts the PHP regions back into line
+func restorePHP(line string, fragments []string) string {
+	for i, v := range fragments {
+		line = strings.Replace(line, "[PHP-"+strconv.Itoa(i)+"]", v, 1)
+	}
 
-	return match != nil && len(match) == 3 && match[2] != "{php"
+	return line
 }
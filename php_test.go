@@ -6,17 +6,22 @@ package main
 
 import "testing"
 
-// ------------ SCRIPT TAGS
+// ------------ PHP TAGS
 var openPHPTags = []string{
 	`{php} random test`,
 	`rando{ñm test} {php}`,
 	`  yo-!    {php}      // random comment`,
+	`var config = <?php echo json_encode(`,
+	`<?=`,
+	`<? if ($a) { ?> b <?php`,
 }
 
 var closePHPTags = []string{
 	`anything {/php} //  comment`,
 	`{/php}`,
 	`  yo-!    {/php}       // random comment`,
+	`); ?>;`,
+	`} ?>`,
 }
 
 var nonPHPTags = []string{
@@ -52,36 +57,85 @@ var nonPHPTags = []string{
 	`{rdelim});`,
 	`return o;`,
 	`{php}{/php}`,
+	`{php} echo '{a}'; {/php} var b = {c: 1}`,
+	`var config = <?php echo json_encode($config) ?>, d = {e: 1}`,
+	`<?= $title ?> <?php } ?>`,
+	`<?xml version="1.0" encoding="UTF-8"?>`,
 }
 
 func TestStartPHPTags(t *testing.T) {
-	for _, line := range openLiteralTags {
-		if !startOfLiteralTag(line) {
+	for _, line := range openPHPTags {
+		if !startOfPHPTag(line) {
 			t.Fatalf("Should be php tag %s", line)
 		}
 	}
 }
 
 func TestStartPHPTagNonTags(t *testing.T) {
-	for _, line := range closeLiteralTags {
-		if startOfLiteralTag(line) {
+	for _, line := range nonPHPTags {
+		if startOfPHPTag(line) {
 			t.Fatalf("Should not be php tag %s", line)
 		}
 	}
 }
 
 func TestEndPHPTags(t *testing.T) {
-	for _, line := range closeLiteralTags {
-		if !endOfLiteralTag(line) {
+	for _, line := range closePHPTags {
+		if !endOfPHPTag(line) {
 			t.Fatalf("Should be php tag %s", line)
 		}
 	}
 }
 
 func TestEndPHPTagNonTags(t *testing.T) {
-	for _, line := range nonLiteralTags {
-		if endOfLiteralTag(line) {
+	for _, line := range nonPHPTags {
+		if endOfPHPTag(line) {
 			t.Fatalf("Should not be php tag %s", line)
 		}
 	}
 }
+
+func TestMaskPHP(t *testing.T) {
+	line := `var a = <?= json_encode($a) ?>, b = {php}echo '{';{/php}, c = {}`
+	exp := `var a = [PHP-0], b = [PHP-1], c = {}`
+
+	masked, fragments := maskPHP(line)
+
+	if masked != exp {
+		t.Fatalf("Expected masked line: %s; got: %s", exp, masked)
+	}
+
+	if r := restorePHP(masked, fragments); r != line {
+		t.Fatalf("Expected restored line: %s; got: %s", line, r)
+	}
+}
+
+func TestMaskPHPLine(t *testing.T) {
+	lines := []string{
+		"  $y = 1; ?> var a = {x: 1}; <?= $b ?>\n",
+		"?></script>\n",
+		"var c = {}; <?php\n",
+	}
+
+	inside := []bool{true, true, false}
+
+	expected := []string{
+		"[PHP-0] var a = {x: 1}; [PHP-1]\n",
+		"[PHP-0]</script>\n",
+		"var c = {}; [PHP-0]\n",
+	}
+
+	open := []bool{false, false, true}
+
+	for i, line := range lines {
+		masked, fragments, o := maskPHPLine(line, inside[i])
+
+		if masked != expected[i] || o != open[i] {
+			t.Fatalf("Expected masked line: %s %v; got: %s %v", expected[i], open[i], masked, o)
+		}
+
+		if r := restorePHP(masked, fragments); r != line {
+			t.Fatalf("Expected restored line: %s; got: %s", line, r)
+		}
+	}
+}
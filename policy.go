@@ -0,0 +1,174 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ------------ POLICIES
+
+// policy is what both parses do with the braces of a script context
+type policy int
+
+const (
+	// keepPolicy leaves the context untouched
+	keepPolicy policy = iota
+
+	// convertPolicy follows the code, braces are escaped on brace parse
+	// and escape tags turned back into braces on delim parse
+	convertPolicy
+
+	// escapePolicy escapes braces on brace parse and leaves the escape tags
+	// on delim parse, so the template engine never meets a bare brace
+	escapePolicy
+)
+
+var policyNames = map[string]policy{
+	"keep":    keepPolicy,
+	"convert": convertPolicy,
+	"escape":  escapePolicy,
+}
+
+// contextNames are the script contexts a policy can be given to
+var contextNames = map[string]segmentKind{
+	"string":   stringSegment,
+	"template": templateSegment,
+	"line":     lineCommentSegment,
+	"block":    blockCommentSegment,
+	"regex":    regexSegment,
+}
+
+// defaultPolicies escape strings, which Smarty would otherwise parse, and
+// keep template literal text, comments and regex literals as they are
+func defaultPolicies() map[segmentKind]policy {
+	return map[segmentKind]policy{
+		stringSegment:       escapePolicy,
+		templateSegment:     keepPolicy,
+		lineCommentSegment:  keepPolicy,
+		blockCommentSegment: keepPolicy,
+		regexSegment:        keepPolicy,
+	}
+}
+
+// parsePolicies sets the policies given as a comma separated list of
+// context=policy pairs, eg. string=convert,line=escape
+func parsePolicies(spec string, policies map[segmentKind]policy) error {
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("Policy must be context=policy: %s", pair)
+		}
+
+		kind, ok := contextNames[strings.TrimSpace(kv[0])]
+		if !ok {
+			return fmt.Errorf("Unknown policy context: %s", kv[0])
+		}
+
+		p, ok := policyNames[strings.TrimSpace(kv[1])]
+		if !ok {
+			return fmt.Errorf("Unknown policy: %s", kv[1])
+		}
+
+		policies[kind] = p
+	}
+
+	return nil
+}
+
+// applyPolicy converts the text of a context according to p, brace telling
+// the direction of the parse
+func (d dialect) applyPolicy(p policy, text string, brace bool, style string) string {
+	switch {
+	case p == keepPolicy:
+		return text
+	case brace:
+		return d.escapeText(text, style)
+	case p == convertPolicy:
+		return d.unescapeText(text)
+	}
+
+	return text
+}
+
+// escapeText rewrites the bare braces of text to the tags of style, leaving
+// Smarty tags and the braces an autoLiteral dialect would not parse
+func (d dialect) escapeText(text, style string) string {
+	var nText string
+	var kept []bool
+
+	tags, _ := d.escapeStyle(style)
+
+	for i := 0; i < len(text); i++ {
+		if _, tag, ok := d.escapeTagAt(text[i:]); ok {
+			nText += tag
+			i += len(tag) - 1
+			continue
+		}
+
+		if d.isSmartyTagStart(text[i:]) {
+			if end := smartyTagEnd(text, i+1); end > 0 {
+				nText += text[i:end]
+				i = end - 1
+				continue
+			}
+		}
+
+		switch text[i] {
+		case '{':
+			escape := !d.autoLiteral || i+1 == len(text) || !isSpace(text[i+1])
+			kept = append(kept, escape)
+
+			if escape {
+				nText += tags.Left
+				continue
+			}
+		case '}':
+			// a lone right brace is plain text to an autoLiteral dialect
+			escape := !d.autoLiteral
+
+			if len(kept) > 0 {
+				escape = kept[len(kept)-1]
+				kept = kept[:len(kept)-1]
+			}
+
+			if escape {
+				nText += tags.Right
+				continue
+			}
+		}
+
+		nText += string(text[i])
+	}
+
+	return nText
+}
+
+// unescapeText rewrites the escape tags of text to braces
+func (d dialect) unescapeText(text string) string {
+	var nText string
+
+	for i := 0; i < len(text); i++ {
+		if token, tag, ok := d.escapeTagAt(text[i:]); ok {
+			if token == leftDelim {
+				nText += "{"
+			} else {
+				nText += "}"
+			}
+
+			i += len(tag) - 1
+			continue
+		}
+
+		nText += string(text[i])
+	}
+
+	return nText
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestParsePolicies(t *testing.T) {
+	policies := defaultPolicies()
+
+	if err := parsePolicies("string=convert, line=escape,", policies); err != nil {
+		t.Fatal(err)
+	}
+
+	if policies[stringSegment] != convertPolicy {
+		t.Fatalf("Expected string policy: %d; got: %d", convertPolicy, policies[stringSegment])
+	}
+
+	if policies[lineCommentSegment] != escapePolicy {
+		t.Fatalf("Expected line policy: %d; got: %d", escapePolicy, policies[lineCommentSegment])
+	}
+
+	if policies[regexSegment] != keepPolicy {
+		t.Fatalf("Expected regex policy: %d; got: %d", keepPolicy, policies[regexSegment])
+	}
+
+	invalid := []string{"string", "html=keep", "string=drop"}
+
+	for _, spec := range invalid {
+		if err := parsePolicies(spec, defaultPolicies()); err == nil {
+			t.Fatalf("Expected error on policy: %s", spec)
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	texts := []string{
+		`{a: 1}`,
+		`{ldelim}a{rdelim} {b}`,
+		`{$var} {c}`,
+		`{ a } {b}`,
+	}
+
+	expected := []string{
+		`{ldelim}a: 1{rdelim}`,
+		`{ldelim}a{rdelim} {ldelim}b{rdelim}`,
+		`{$var} {ldelim}c{rdelim}`,
+		`{ldelim} a {rdelim} {ldelim}b{rdelim}`,
+	}
+
+	for i, text := range texts {
+		if r := dialects["smarty2"].escapeText(text, ""); r != expected[i] {
+			t.Fatalf("Expected: %s; got: %s", expected[i], r)
+		}
+	}
+
+	if r := dialects["smarty3"].escapeText(`{ a } {b}`, "smarty"); r != `{ a } {$smarty.ldelim}b{$smarty.rdelim}` {
+		t.Fatalf("Expected only the braces smarty3 parses escaped; got: %s", r)
+	}
+}
+
+func TestEscapeTextAutoLiteral(t *testing.T) {
+	texts := []string{
+		`}`,
+		`} else {`,
+		`} else { a }`,
+		`{b} }`,
+		`}) {c}`,
+	}
+
+	expected := []string{
+		`}`,
+		`} else {ldelim}`,
+		`} else { a }`,
+		`{ldelim}b{rdelim} }`,
+		`}) {ldelim}c{rdelim}`,
+	}
+
+	for i, text := range texts {
+		r := dialects["smarty3"].escapeText(text, "")
+
+		if r != expected[i] {
+			t.Fatalf("Expected: %s; got: %s", expected[i], r)
+		}
+
+		// the delim parse keeps the same braces escaped
+		if d := autoLiteralDelims(dialects["smarty2"].escapeText(text, "")); d != r {
+			t.Fatalf("Expected escapeText to agree with autoLiteralDelims: %s; got: %s", d, r)
+		}
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	text := `{ldelim}a{rdelim} {$smarty.ldelim}b{$smarty.rdelim} {'{'} {$var}`
+	exp := `{a} {b} { {$var}`
+
+	if r := dialects["smarty2"].unescapeText(text); r != exp {
+		t.Fatalf("Expected: %s; got: %s", exp, r)
+	}
+}
+
+func TestApplyPolicy(t *testing.T) {
+	d := dialects["smarty2"]
+	policies := []policy{keepPolicy, convertPolicy, escapePolicy}
+	expBrace := []string{`{a} {ldelim}`, `{ldelim}a{rdelim} {ldelim}`, `{ldelim}a{rdelim} {ldelim}`}
+	expDelim := []string{`{a} {ldelim}`, `{a} {`, `{a} {ldelim}`}
+
+	for i, p := range policies {
+		if r := d.applyPolicy(p, `{a} {ldelim}`, true, ""); r != expBrace[i] {
+			t.Fatalf("Expected brace parse: %s; got: %s", expBrace[i], r)
+		}
+
+		if r := d.applyPolicy(p, `{a} {ldelim}`, false, ""); r != expDelim[i] {
+			t.Fatalf("Expected delim parse: %s; got: %s", expDelim[i], r)
+		}
+	}
+}
@@ -4,21 +4,68 @@
 
 package main
 
-import "regexp"
+// ------------ REGEX LITERALS
 
-func isRegExp(line string) bool {
-	re := `(.*?[^\/]?)\/(.+)\/(.*)`
+// keywords after which a slash starts a regex literal rather than a division
+var regexKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true,
+	"new": true, "delete": true, "void": true, "throw": true, "case": true,
+	"do": true, "else": true, "yield": true, "await": true,
+}
+
+// regexAllowedAfter tells whether a slash following the prev token starts
+// a regex literal, after an operand such as a name, a literal or a closing
+// bracket it is a division
+func regexAllowedAfter(prev string) bool {
+	if prev == "" {
+		return true
+	}
+
+	if isIdentChar(prev[len(prev)-1]) {
+		return regexKeywords[prev]
+	}
+
+	switch prev {
+	case ")", "]", "}":
+		return false
+	}
 
-	return regexp.MustCompile(re).MatchString(line)
+	return true
 }
 
-func parseRegExp(line string) ([]string, bool) {
-	re := `(.*?[^\/]?)(\/.+\/)(.*)`
-	matches := regexp.MustCompile(re).FindStringSubmatch(line)
+// regexEnd returns the index after the regex literal and its flags starting
+// with the slash at i, or i when the line does not hold a complete literal
+func regexEnd(line string, i int) int {
+	var inClass bool
+
+	for j := i + 1; j < len(line); j++ {
+		switch c := line[j]; {
+		case c == '\\':
+			j++
+		case c == '\n' || c == '\r':
+			return i
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			if j == i+1 {
+				return i
+			}
+
+			j++
 
-	if matches == nil {
-		return nil, false
+			for j < len(line) && isIdentChar(line[j]) {
+				j++
+			}
+
+			return j
+		}
 	}
 
-	return []string{matches[1], matches[2], matches[3]}, true
+	return i
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
 }
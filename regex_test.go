@@ -12,82 +12,65 @@ var regExpPatterns = []string{
 	`return exec(/^[a-zA-Z]{1,2}[0-9]{2,3}$/, value)`,
 	`return exec(/^[0-9]{7,10}$/, value)`,
 	`return exec(/^\w{6}$/, value)`,
-	`r2 regex /(.*?[^\/])\/(.+)\/(.*)/`,
-	`commen ml start /(.*)\/\*\*(.*) (.*)\*\/(.*)/`,
-	`commen ml start /(.*)\{\*\*(.*) (.*)\*}(.*)/ smarty`,
-	`line comment /(.*)(\/\/.*)/ [1]check [2]append`,
-	`partial comment /(.*)(\/\*.*\*\/)(.*)/`,
+	`var r2 = /(.*?[^\/])\/(.+)\/(.*)/`,
+	`commentStart = /(.*)\/\*\*(.*) (.*)\*\/(.*)/g`,
+	`if (/(.*)\{\*\*(.*) (.*)\*}(.*)/.test(smarty)) {`,
+	`return /(.*)(\/\/.*)/ // [1]check [2]append`,
+	`partial = [/(.*)(\/\*.*\*\/)(.*)/]`,
+	`let date = line.match(/\d{2,4}/gi) || /[/{}]+/.exec(line)`,
+	`const t = !/a{2}/i.test(b) ? x / y : z`,
 }
 
 var expectRegExpPatterns = [][]string{
-	[]string{`return exec(`, `/^[0-9]{11}$/`, `, value)`},
-	[]string{`return exec(`, `/^[0-9]{2}$/`, `, value)`},
-	[]string{`return exec(`, `/^[a-zA-Z]{1,2}[0-9]{2,3}$/`, `, value)`},
-	[]string{`return exec(`, `/^[0-9]{7,10}$/`, `, value)`},
-	[]string{`return exec(`, `/^\w{6}$/`, `, value)`},
-	[]string{`r2 regex `, `/(.*?[^\/])\/(.+)\/(.*)/`, ``},
-	[]string{`commen ml start `, `/(.*)\/\*\*(.*) (.*)\*\/(.*)/`, ``},
-	[]string{`commen ml start `, `/(.*)\{\*\*(.*) (.*)\*}(.*)/`, ` smarty`},
-	[]string{`line comment `, `/(.*)(\/\/.*)/`, ` [1]check [2]append`},
-	[]string{`partial comment `, `/(.*)(\/\*.*\*\/)(.*)/`, ``},
+	[]string{`/^[0-9]{11}$/`},
+	[]string{`/^[0-9]{2}$/`},
+	[]string{`/^[a-zA-Z]{1,2}[0-9]{2,3}$/`},
+	[]string{`/^[0-9]{7,10}$/`},
+	[]string{`/^\w{6}$/`},
+	[]string{`/(.*?[^\/])\/(.+)\/(.*)/`},
+	[]string{`/(.*)\/\*\*(.*) (.*)\*\/(.*)/g`},
+	[]string{`/(.*)\{\*\*(.*) (.*)\*}(.*)/`},
+	[]string{`/(.*)(\/\/.*)/`},
+	[]string{`/(.*)(\/\*.*\*\/)(.*)/`},
+	[]string{`/\d{2,4}/gi`, `/[/{}]+/`},
+	[]string{`/a{2}/i`},
 }
 
 var nonRegExpPatterns = []string{
-	`(.*)(\{)(.+(}))?(.*)(.+)?({)$ ldelim | $1{ldelim}`,
-	`(.*)({)(.*)(})(.*)(\s+)(}) rdelim | $1{rdelim}`,
-	`({)([^ldelim\n]\w+:\s?\"?\'?.+\"?\'?[^ldelim\n])(}) `,
-	`rc1 (.*)(({).*)(\})(.*)`,
-	`r2 (.*[\=\(\[\{]\s?({))(\w+\s*.+:\s*.+)(})`,
-	`<body>`,
-	`{$some_variable}`,
-	`Outside the script tag may be pure html or may not`,
-	`<script type="text/javascript">`,
+	`let half = a / b / c`,
+	`let ratio = (a + b) / 2 / {$total}`,
+	`let url = "http://example.com/path/"`,
+	`location.href = 'https://example.com/' // see /docs/`,
+	`/* {ldelim} */ let x = y`,
 	`let myVar = {json_decode($jsonVariable)}`,
-	`let myOtherVar = '{$wuuuu}'`,
 	`console.log({include file=$myCustomFile})`,
-	`funcion () {`,
-	`}`,
-	`call({`,
-	`hello: "world"`,
-	`}, {`,
-	`world: "hello"`,
-	`})`,
-	`let array = [{`,
-	`hello: "world",`,
-	`myObject:{`,
-	`one: 1,`,
-	`two: [2, 2]`,
-	`}`,
-	`}]`,
-	`const strangeObject = {maybe: {it: {wont: {work: "?"`,
-	`}, maybe: ""}, not: "did"}, work: "entirely"}`,
+	`let n = items.length / 2, m = total /2`,
+	`let q = litin / 2 / 3, r = lit / 4 / 5`,
+	`<script type="text/javascript">`,
 	`</script>`,
-	`</body>`,
 }
 
-func TestIsRegExpMatch(t *testing.T) {
-	for _, p := range regExpPatterns {
-		if !isRegExp(p) {
-			t.Fatalf("Should be a RegExp Pattern %s", p)
-		}
-	}
-}
+func scanRegExps(lines ...string) []string {
+	var regexps []string
+	s := &jsScanner{dialect: dialects["smarty2"]}
 
-func TestIsRegExpNoMatch(t *testing.T) {
-	for _, p := range nonRegExpPatterns {
-		if isRegExp(p) {
-			t.Fatalf("Should not be a RegExp Pattern %s", p)
+	for _, line := range lines {
+		for _, seg := range s.scan(line) {
+			if seg.kind == regexSegment {
+				regexps = append(regexps, seg.text)
+			}
 		}
 	}
+
+	return regexps
 }
 
-func TestRegExpMatch(t *testing.T) {
+func TestScanRegExpMatch(t *testing.T) {
 	for i, p := range regExpPatterns {
+		result := scanRegExps(p)
 
-		result, match := parseRegExp(p)
-
-		if !match {
-			t.Fatalf("Should match RegExp to be: %s", p)
+		if len(result) != len(expectRegExpPatterns[i]) {
+			t.Fatalf("Expected RegExps %v in %s; got: %v", expectRegExpPatterns[i], p, result)
 		}
 
 		for z, ep := range expectRegExpPatterns[i] {
@@ -95,16 +78,44 @@ func TestRegExpMatch(t *testing.T) {
 				t.Fatalf("Expected RegExp to be: %s; got: %s", ep, result[z])
 			}
 		}
-
 	}
 }
 
-func TestRegExpNoMatch(t *testing.T) {
+func TestScanRegExpNoMatch(t *testing.T) {
 	for _, p := range nonRegExpPatterns {
-		_, match := parseRegExp(p)
+		if result := scanRegExps(p); len(result) > 0 {
+			t.Fatalf("Should not match RegExp in %s; got: %v", p, result)
+		}
+	}
+}
+
+func TestScanRegExpPreviousLine(t *testing.T) {
+	result := scanRegExps("validate(value,", "  /^\\d{5}$/)")
+
+	if len(result) != 1 || result[0] != `/^\d{5}$/` {
+		t.Fatalf("Expected RegExp after the previous line comma; got: %v", result)
+	}
+
+	result = scanRegExps("let total = count", "  / 2 / {$pages}")
+
+	if len(result) > 0 {
+		t.Fatalf("Expected division after the previous line operand; got: %v", result)
+	}
+}
+
+func TestRegexAllowedAfter(t *testing.T) {
+	allowed := []string{"", "(", ",", "=", "return", "typeof", "!", "&", "{"}
+	notAllowed := []string{")", "]", "}", "lit", "value", "2", "returned"}
+
+	for _, p := range allowed {
+		if !regexAllowedAfter(p) {
+			t.Fatalf("Expected a regex to be allowed after %q", p)
+		}
+	}
 
-		if match {
-			t.Fatalf("Should not match RegExp to be: %s", p)
+	for _, p := range notAllowed {
+		if regexAllowedAfter(p) {
+			t.Fatalf("Expected a division after %q", p)
 		}
 	}
 }
@@ -0,0 +1,278 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "strings"
+
+// ------------ SCANNER
+
+type segmentKind int
+
+const (
+	codeSegment segmentKind = iota
+	stringSegment
+	templateSegment
+	templateOpenSegment
+	templateCloseSegment
+	lineCommentSegment
+	blockCommentSegment
+	smartyCommentSegment
+	regexSegment
+	smartyTagSegment
+)
+
+// segment is a run of a script line sharing the same lexical context
+type segment struct {
+	kind segmentKind
+	text string
+}
+
+// jsScanner splits script lines into segments, carrying the context of
+// template literals, block comments and continued strings over lines
+type jsScanner struct {
+	dialect dialect
+	kind    segmentKind
+	quote   byte
+
+	// exprs holds the brace depth of every open ${ } template expression
+	exprs []int
+
+	// tag follows a Smarty tag going on over lines
+	tag smartyTag
+
+	// prev is the last code token met, telling a regex literal from a
+	// division, gap whether a space followed it and lit whether it was a
+	// string, template or regex literal
+	prev string
+	gap  bool
+	lit  bool
+}
+
+func (s *jsScanner) scan(line string) []segment {
+	var segs []segment
+	start := 0
+
+	if s.kind == templateOpenSegment || s.kind == templateCloseSegment || s.kind == lineCommentSegment {
+		s.kind = codeSegment
+	}
+
+	emit := func(end int, next segmentKind) {
+		if end > start {
+			segs = append(segs, segment{s.kind, line[start:end]})
+		}
+
+		start = end
+		s.kind = next
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch s.kind {
+		case blockCommentSegment:
+			if strings.HasPrefix(line[i:], "*/") {
+				i++
+				emit(i+1, codeSegment)
+			}
+		case smartyCommentSegment:
+			if strings.HasPrefix(line[i:], "*}") {
+				i++
+				emit(i+1, codeSegment)
+			}
+		case stringSegment:
+			if c == '\\' {
+				i++
+			} else if end := smartyTagEnd(line, i+1); s.dialect.isSmartyTagStart(line[i:]) && end > 0 {
+				// quoted arguments of a Smarty tag do not end the string
+				i = end - 1
+			} else if c == s.quote {
+				emit(i+1, codeSegment)
+				s.literal()
+			}
+		case templateSegment:
+			if c == '\\' {
+				i++
+			} else if c == '`' {
+				emit(i+1, codeSegment)
+				s.literal()
+			} else if open := s.templateOpenAt(line[i:]); open != "" {
+				emit(i, templateOpenSegment)
+				emit(i+len(open), codeSegment)
+				s.exprs = append(s.exprs, 0)
+				s.prev, s.lit = "{", false
+				i += len(open) - 1
+			}
+		case smartyTagSegment:
+			if s.tag.next(c) {
+				emit(i+1, codeSegment)
+				s.prev, s.lit = "}", false
+			}
+		case codeSegment:
+			i = s.scanCode(line, i, emit)
+		}
+	}
+
+	emit(len(line), s.kind)
+
+	// a string only goes on to the next line when escaping the line break
+	if s.kind == stringSegment && !strings.HasSuffix(strings.TrimRight(line, "\r\n"), "\\") {
+		s.kind = codeSegment
+	}
+
+	return segs
+}
+
+// scanCode handles the code character at i returning the last index used
+func (s *jsScanner) scanCode(line string, i int, emit func(int, segmentKind)) int {
+	c := line[i]
+
+	switch {
+	case c == '"' || c == '\'':
+		emit(i, stringSegment)
+		s.quote = c
+	case c == '`':
+		emit(i, templateSegment)
+	case strings.HasPrefix(line[i:], "//") || isHTMLCommentAt(line, i):
+		emit(i, lineCommentSegment)
+		return s.lineCommentEnd(line, i, emit)
+	case strings.HasPrefix(line[i:], "/*"):
+		emit(i, blockCommentSegment)
+		return i + 1
+	case strings.HasPrefix(line[i:], "{*"):
+		emit(i, smartyCommentSegment)
+		return i + 1
+	// a slash right after < closes an html tag such as </script>
+	case c == '/' && (s.gap || s.prev != "<") && !s.lit && regexAllowedAfter(s.prev):
+		if end := regexEnd(line, i); end > i {
+			emit(i, regexSegment)
+			emit(end, codeSegment)
+			s.literal()
+			return end - 1
+		}
+	case isSpace(c):
+		s.gap = true
+		return i
+	case c == '{':
+		token, text, ok := s.dialect.escapeTagAt(line[i:])
+
+		if ok && token == rightDelim {
+			return s.closeBrace(i, len(text), emit)
+		}
+
+		if !ok && s.dialect.isSmartyTagStart(line[i:]) {
+			emit(i, smartyTagSegment)
+			s.tag = smartyTag{}
+			return i
+		}
+
+		if len(s.exprs) > 0 {
+			s.exprs[len(s.exprs)-1]++
+		}
+
+		s.token('{')
+
+		if ok {
+			return i + len(text) - 1
+		}
+
+		return i
+	case c == '}':
+		return s.closeBrace(i, 1, emit)
+	}
+
+	s.token(c)
+
+	return i
+}
+
+// isHTMLCommentAt tells whether the code at i is an html comment marker
+// browsers take for a line comment within scripts: <!-- anywhere or -->
+// opening the line
+func isHTMLCommentAt(line string, i int) bool {
+	if strings.HasPrefix(line[i:], "<!--") {
+		return true
+	}
+
+	return strings.HasPrefix(line[i:], "-->") && strings.TrimSpace(line[:i]) == ""
+}
+
+// lineCommentEnd returns the last index of the line comment opened at i, a
+// </script> closing the script even within a comment
+func (s *jsScanner) lineCommentEnd(line string, i int, emit func(int, segmentKind)) int {
+	end := strings.Index(line[i:], "</script")
+	if end < 0 {
+		return len(line) - 1
+	}
+
+	emit(i+end, codeSegment)
+
+	return i + end - 1
+}
+
+// token records the code character c as part of the previous token
+func (s *jsScanner) token(c byte) {
+	if isIdentChar(c) && !s.gap && !s.lit && s.prev != "" && isIdentChar(s.prev[len(s.prev)-1]) {
+		s.prev += string(c)
+	} else {
+		s.prev = string(c)
+	}
+
+	s.gap = false
+	s.lit = false
+}
+
+// continues tells whether the scanner stopped within a template literal,
+// one of its expressions included, or within a string going on to the next
+// line
+func (s *jsScanner) continues() bool {
+	return s.kind == templateSegment || s.kind == stringSegment || len(s.exprs) > 0
+}
+
+// literal records a string, template or regex literal as the previous token
+func (s *jsScanner) literal() {
+	s.prev = ""
+	s.gap = false
+	s.lit = true
+}
+
+// closeBrace ends the template expression the right brace at i belongs to
+func (s *jsScanner) closeBrace(i, size int, emit func(int, segmentKind)) int {
+	s.token('}')
+
+	if len(s.exprs) == 0 {
+		return i + size - 1
+	}
+
+	last := len(s.exprs) - 1
+
+	if s.exprs[last] > 0 {
+		s.exprs[last]--
+		return i + size - 1
+	}
+
+	s.exprs = s.exprs[:last]
+	emit(i, templateCloseSegment)
+	emit(i+size, templateSegment)
+
+	return i + size - 1
+}
+
+// templateOpenAt returns the ${ opening a template expression at the start
+// of line, either bare or with an escaped left brace
+func (s *jsScanner) templateOpenAt(line string) string {
+	if !strings.HasPrefix(line, "$") {
+		return ""
+	}
+
+	if strings.HasPrefix(line, "${") {
+		if token, text, ok := s.dialect.escapeTagAt(line[1:]); ok && token == leftDelim {
+			return "$" + text
+		}
+
+		return "${"
+	}
+
+	return ""
+}
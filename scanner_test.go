@@ -0,0 +1,125 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestScan(t *testing.T) {
+	lines := []string{
+		"var a = 'it\\'s {b}'; // {c}",
+		"var a = `x ${b} y`",
+		"var a = /* {b} */ c",
+		"var a = {* {b} *} c",
+		"var a = 'x {$b|default:'y'} z'",
+		"<!-- var a = {b}",
+		"  --> {c}",
+		"var a = b--> c",
+		"var a = {b}; // --></script>",
+	}
+
+	expected := [][]segment{
+		{{codeSegment, "var a = "}, {stringSegment, "'it\\'s {b}'"}, {codeSegment, "; "}, {lineCommentSegment, "// {c}"}},
+		{{codeSegment, "var a = "}, {templateSegment, "`x "}, {templateOpenSegment, "${"}, {codeSegment, "b"}, {templateCloseSegment, "}"}, {templateSegment, " y`"}},
+		{{codeSegment, "var a = "}, {blockCommentSegment, "/* {b} */"}, {codeSegment, " c"}},
+		{{codeSegment, "var a = "}, {smartyCommentSegment, "{* {b} *}"}, {codeSegment, " c"}},
+		{{codeSegment, "var a = "}, {stringSegment, "'x {$b|default:'y'} z'"}},
+		{{lineCommentSegment, "<!-- var a = {b}"}},
+		{{codeSegment, "  "}, {lineCommentSegment, "--> {c}"}},
+		{{codeSegment, "var a = b--> c"}},
+		{{codeSegment, "var a = {b}; "}, {lineCommentSegment, "// -->"}, {codeSegment, "</script>"}},
+	}
+
+	for i, line := range lines {
+		s := &jsScanner{dialect: dialects["smarty2"]}
+		segs := s.scan(line)
+
+		if len(segs) != len(expected[i]) {
+			t.Fatalf("Expected segments %v; got: %v", expected[i], segs)
+		}
+
+		for j, seg := range segs {
+			if seg != expected[i][j] {
+				t.Fatalf("Expected segment %v; got: %v", expected[i][j], seg)
+			}
+		}
+	}
+}
+
+func TestScanMultiline(t *testing.T) {
+	lines := []string{
+		"var a = `",
+		"  {b} ${c.map(d => `${d}`)}",
+		"` + {}",
+	}
+
+	expected := [][]segmentKind{
+		{codeSegment, templateSegment},
+		{templateSegment, templateOpenSegment, codeSegment, templateSegment, templateOpenSegment, codeSegment, templateCloseSegment, templateSegment, codeSegment, templateCloseSegment},
+		{templateSegment, codeSegment},
+	}
+
+	s := &jsScanner{dialect: dialects["smarty2"]}
+
+	for i, line := range lines {
+		segs := s.scan(line)
+
+		if len(segs) != len(expected[i]) {
+			t.Fatalf("Expected %d segments on line %d; got: %v", len(expected[i]), i+1, segs)
+		}
+
+		for j, seg := range segs {
+			if seg.kind != expected[i][j] {
+				t.Fatalf("Expected segment kind %d on line %d; got: %v", expected[i][j], i+1, seg)
+			}
+		}
+	}
+}
+
+func TestScanSmartyTags(t *testing.T) {
+	lines := []string{
+		"var a = {b: {$c}, d: {include file=\"e.tpl\"",
+		"  assign='}'} f: {}}",
+	}
+
+	expected := [][]segment{
+		{{codeSegment, "var a = {b: "}, {smartyTagSegment, "{$c}"}, {codeSegment, ", d: "}, {smartyTagSegment, "{include file=\"e.tpl\""}},
+		{{smartyTagSegment, "  assign='}'}"}, {codeSegment, " f: {}}"}},
+	}
+
+	s := &jsScanner{dialect: dialects["smarty2"]}
+
+	for i, line := range lines {
+		segs := s.scan(line)
+
+		if len(segs) != len(expected[i]) {
+			t.Fatalf("Expected segments %v; got: %v", expected[i], segs)
+		}
+
+		for j, seg := range segs {
+			if seg != expected[i][j] {
+				t.Fatalf("Expected segment %v; got: %v", expected[i][j], seg)
+			}
+		}
+	}
+}
+
+func TestScanContinues(t *testing.T) {
+	lines := []string{
+		"var s = `first",
+		"  ${ {a: 1}.a",
+		"  } line`; var t = 'one \\",
+		"  two';",
+	}
+
+	expected := []bool{true, true, true, false}
+
+	s := &jsScanner{dialect: dialects["smarty2"]}
+
+	for i, line := range lines {
+		if s.scan(line); s.continues() != expected[i] {
+			t.Fatalf("Expected continues %t after: %s", expected[i], line)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ------------ SMARTY TAGS
+
+// smartyFunctions are the built-in Smarty functions and blocks, the tags
+// of the Smarty dialects
+var smartyFunctions = map[string]bool{
+	"append": true, "assign": true, "block": true, "break": true, "call": true,
+	"capture": true, "config_load": true, "continue": true, "counter": true,
+	"cycle": true, "debug": true, "else": true, "elseif": true, "eval": true,
+	"extends": true, "fetch": true, "for": true, "foreach": true,
+	"foreachelse": true, "function": true, "html_checkboxes": true,
+	"html_image": true, "html_options": true, "html_radios": true,
+	"html_select_date": true, "html_select_time": true, "html_table": true,
+	"if": true, "include": true, "include_php": true, "insert": true,
+	"mailto": true, "math": true, "nocache": true, "section": true,
+	"sectionelse": true, "setfilter": true, "strip": true, "textformat": true,
+	"while": true,
+}
+
+// pluginFile matches the Smarty plugin file names, eg. function.name.php
+var pluginFile = regexp.MustCompile(`^(function|block|compiler|insert|modifier)\.(\w+)\.php$`)
+
+// withPlugins returns d knowing the plugin names as tags as well, a
+// directory adds the plugins it holds
+func (d dialect) withPlugins(names []string) (dialect, error) {
+	tags := map[string]bool{}
+
+	for name := range d.tags {
+		tags[name] = true
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+
+		if info, err := os.Stat(name); err == nil && info.IsDir() {
+			files, err := ioutil.ReadDir(name)
+			if err != nil {
+				return d, err
+			}
+
+			for _, f := range files {
+				if m := pluginFile.FindStringSubmatch(f.Name()); m != nil && m[1] != "modifier" {
+					tags[m[2]] = true
+				}
+			}
+
+			continue
+		}
+
+		if name == "" || strings.IndexFunc(name, func(r rune) bool { return r > 127 || !isIdentChar(byte(r)) }) >= 0 {
+			return d, fmt.Errorf("Invalid plugin name: %s", name)
+		}
+
+		tags[name] = true
+	}
+
+	d.tags = tags
+
+	return d, nil
+}
+
+// isSmartyTagStart tells whether line starts with the left brace of a Smarty
+// tag: a variable {$var}, a config variable {#conf#}, a closing tag {/if},
+// a quoted string followed by a modifier {"text"|upper}, a PHP function call
+// {func($a)} or one of the dialect tags {include file="x.tpl"}
+func (d dialect) isSmartyTagStart(line string) bool {
+	if len(line) < 3 || line[0] != '{' {
+		return false
+	}
+
+	switch line[1] {
+	case '$', '/':
+		return isIdentChar(line[2]) && line[2] != '$'
+	case '#':
+		// a config variable is closed by a second hash, {#name with none is
+		// a JS private class field
+		end := 2
+		for end < len(line) && (isIdentChar(line[end]) || line[end] == '.') {
+			end++
+		}
+
+		return end > 2 && end < len(line) && line[end] == '#'
+	case '"', '\'':
+		end := strings.IndexByte(line[2:], line[1])
+
+		return end >= 0 && strings.HasPrefix(line[end+3:], "|")
+	}
+
+	end := 1
+	for end < len(line) && isIdentChar(line[end]) {
+		end++
+	}
+
+	if end == 1 {
+		return false
+	}
+
+	// a PHP function call passes template variables, otherwise it is a JS
+	// block such as {start()} or {init({count: {$count}})}
+	if end < len(line) && line[end] == '(' {
+		tagEnd := smartyTagEnd(line, 1)
+
+		if d.tags[line[1:end]] {
+			return true
+		}
+
+		return tagEnd > 0 && strings.Contains(line[end:tagEnd], "$") && !strings.Contains(line[end:tagEnd-1], "{")
+	}
+
+	// a tag may quote its argument right away, eg. the Latte {_"Hello"}
+	if end < len(line) && (line[end] == '"' || line[end] == '\'') {
+		return d.tags[line[1:end]]
+	}
+
+	if end < len(line) && !isSpace(line[end]) && line[end] != '}' {
+		return false
+	}
+
+	return d.tags[line[1:end]]
+}
+
+// smartyTag follows the quoted arguments and nested braces of a Smarty tag
+type smartyTag struct {
+	quote   byte
+	depth   int
+	escaped bool
+}
+
+// next feeds the tag with c telling whether it is its closing right brace
+func (t *smartyTag) next(c byte) bool {
+	switch {
+	case t.escaped:
+		t.escaped = false
+	case t.quote != 0:
+		if c == '\\' {
+			t.escaped = true
+		} else if c == t.quote {
+			t.quote = 0
+		}
+	case c == '"' || c == '\'':
+		t.quote = c
+	case c == '{':
+		t.depth++
+	case c == '}':
+		if t.depth == 0 {
+			return true
+		}
+
+		t.depth--
+	}
+
+	return false
+}
+
+// smartyTagEnd returns the index after the right brace closing the Smarty
+// tag whose content starts at i, or -1 when the tag goes on past the line
+func smartyTagEnd(line string, i int) int {
+	var t smartyTag
+
+	for ; i < len(line); i++ {
+		if t.next(line[i]) {
+			return i + 1
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+var smartyTagStarts = []string{
+	`{$var}`,
+	`{$user.name|escape}`,
+	`{/if}`,
+	`{if $a}`,
+	`{include file="x.tpl"`,
+	`{foreach`,
+	`{else}`,
+	`{#title#}`,
+	`{#site.name#|upper}`,
+	`{"text"|upper}`,
+	`{json_decode($json)}`,
+}
+
+var nonSmartyTagStarts = []string{
+	`{a: 1}`,
+	`{ if: 1}`,
+	`{include: 1}`,
+	`{iffy}`,
+	`{`,
+	`{$}`,
+	`{ldelim}`,
+	`{literal}`,
+	`{lrdelim}`,
+	`{a}`,
+	`{"text"}`,
+	`{#}`,
+	`{#count = 0; inc() {this.#count++}}`,
+	`{#count++}`,
+	`{start()}`,
+	`{init({count: {$count}})}`,
+}
+
+func TestIsSmartyTagStartMatch(t *testing.T) {
+	for _, l := range smartyTagStarts {
+		if !dialects["smarty2"].isSmartyTagStart(l) {
+			t.Fatalf("Should be the start of a Smarty tag: %s", l)
+		}
+	}
+}
+
+func TestIsSmartyTagStartNoMatch(t *testing.T) {
+	for _, l := range nonSmartyTagStarts {
+		if dialects["smarty2"].isSmartyTagStart(l) {
+			t.Fatalf("Should not be the start of a Smarty tag: %s", l)
+		}
+	}
+}
+
+func TestIsSmartyTagStartLatte(t *testing.T) {
+	d := dialects["latte"]
+
+	for _, l := range []string{`{var $x = 1}`, `{ifset $y}`, `{_"Hello"}`, `{$x}`, `{/if}`} {
+		if !d.isSmartyTagStart(l) {
+			t.Fatalf("Should be the start of a Latte tag: %s", l)
+		}
+	}
+
+	for _, l := range []string{`{assign var=x}`, `{_: 1}`, `{"text"}`} {
+		if d.isSmartyTagStart(l) {
+			t.Fatalf("Should not be the start of a Latte tag: %s", l)
+		}
+	}
+
+	if dialects["smarty2"].isSmartyTagStart(`{var $x = 1}`) {
+		t.Fatal("Should not be the start of a Smarty tag: {var $x = 1}")
+	}
+}
+
+func TestSmartyTagEnd(t *testing.T) {
+	lines := []string{
+		`{if $a}b`,
+		`{include file="a}b.tpl"} c`,
+		`{assign var=x value='it\'s }'}`,
+		`{include file="x.tpl"`,
+	}
+
+	expected := []int{7, 24, 30, -1}
+
+	for i, l := range lines {
+		if end := smartyTagEnd(l, 1); end != expected[i] {
+			t.Fatalf("Expected end of %s: %d; got: %d", l, expected[i], end)
+		}
+	}
+}
+
+func TestWithPlugins(t *testing.T) {
+	d, err := dialects["smarty2"].withPlugins([]string{"chart", "files/plugins"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, l := range []string{`{widget id=1}`, `{panel}`, `{chart type="pie"}`, `{include file="x.tpl"}`} {
+		if !d.isSmartyTagStart(l) {
+			t.Fatalf("Should be the start of a registered plugin: %s", l)
+		}
+	}
+
+	if d.isSmartyTagStart(`{money}`) {
+		t.Fatal("Modifiers should not begin a tag")
+	}
+
+	if dialects["smarty2"].isSmartyTagStart(`{chart}`) || smartyFunctions["widget"] {
+		t.Fatal("Should not know the plugins outside the returned dialect")
+	}
+
+	if _, err := d.withPlugins([]string{"bad-name"}); err == nil {
+		t.Fatal("Expected error on invalid plugin name")
+	}
+}
@@ -4,7 +4,10 @@
 
 package main
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
 // ------------ SCRIPT TAGS
 func startOfScriptTag(line string) bool {
@@ -20,3 +23,41 @@ func endOfScriptTag(line string) bool {
 
 	return match != nil && len(match) == 3 && match[2] != "<script"
 }
+
+// stripHTMLComments returns line without its html comments, inside telling
+// whether line starts within one, along with whether a comment goes on past
+// the line
+func stripHTMLComments(line string, inside bool) (string, bool) {
+	return stripComments(line, inside, "<!--", "-->")
+}
+
+// stripSmartyComments is stripHTMLComments for {* *} comments, which Smarty
+// drops before the browser ever sees the markup
+func stripSmartyComments(line string, inside bool) (string, bool) {
+	return stripComments(line, inside, "{*", "*}")
+}
+
+func stripComments(line string, inside bool, open, close string) (string, bool) {
+	var nLine string
+
+	for {
+		if inside {
+			end := strings.Index(line, close)
+			if end < 0 {
+				return nLine, true
+			}
+
+			line = line[end+len(close):]
+			inside = false
+		}
+
+		start := strings.Index(line, open)
+		if start < 0 {
+			return nLine + line, false
+		}
+
+		nLine += line[:start]
+		line = line[start+len(open):]
+		inside = true
+	}
+}
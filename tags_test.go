@@ -84,3 +84,65 @@ func TestEndScriptTagNonTags(t *testing.T) {
 		}
 	}
 }
+
+func TestStripHTMLComments(t *testing.T) {
+	lines := []string{
+		`<!-- <script src="old.js"></script> -->`,
+		`<p>a</p> <!--`,
+		`<script type="text/javascript">`,
+		`--> <script> <!-- b --> c`,
+		`<script><!--`,
+	}
+
+	expected := []string{
+		``,
+		`<p>a</p> `,
+		``,
+		` <script>  c`,
+		`<script>`,
+	}
+
+	expectedInside := []bool{false, true, true, false, true}
+
+	var inside bool
+
+	for i, line := range lines {
+		var html string
+
+		html, inside = stripHTMLComments(line, inside)
+
+		if html != expected[i] || inside != expectedInside[i] {
+			t.Fatalf("Expected html: %q inside: %t; got: %q inside: %t", expected[i], expectedInside[i], html, inside)
+		}
+	}
+}
+
+func TestStripSmartyComments(t *testing.T) {
+	lines := []string{
+		`{* <script type="text/javascript"> *} <p>{$a}</p>`,
+		`{* disabled`,
+		`<script type="text/javascript">`,
+		`*} <script>`,
+	}
+
+	expected := []string{
+		` <p>{$a}</p>`,
+		``,
+		``,
+		` <script>`,
+	}
+
+	expectedInside := []bool{false, true, true, false}
+
+	var inside bool
+
+	for i, line := range lines {
+		var html string
+
+		html, inside = stripSmartyComments(line, inside)
+
+		if html != expected[i] || inside != expectedInside[i] {
+			t.Fatalf("Expected html: %q inside: %t; got: %q inside: %t", expected[i], expectedInside[i], html, inside)
+		}
+	}
+}
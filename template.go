@@ -0,0 +1,180 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ------------ TEMPLATE LITERALS
+
+// templateMasker hides from the brace and delim parses, behind [TPL-n]
+// fragments, the text of JS template literals, which may span several
+// lines, of Smarty tags spanning lines, of regex literals, of comments and
+// of strings depending on their policy, converting it according to the
+// policy of its context. The ${ } braces of template expressions are
+// converted on the way while the expressions themselves are left to the
+// parses as any other code
+type templateMasker struct {
+	scanner *jsScanner
+	brace   bool
+	opts    options
+
+	// escaped tells for every open ${ whether it ended up escaped, so its
+	// right brace follows
+	escaped []bool
+}
+
+func newTemplateMasker(brace bool, opts options) *templateMasker {
+	return &templateMasker{
+		scanner: &jsScanner{dialect: opts.dialect},
+		brace:   brace,
+		opts:    opts,
+	}
+}
+
+// mask returns line with template literal text replaced by fragments
+func (m *templateMasker) mask(line string) (string, []string) {
+	var nLine string
+	var fragments []string
+	var fragment string
+	var inFragment bool
+
+	body := strings.TrimRight(line, "\r\n")
+	eol := line[len(body):]
+	segs := m.scanner.scan(body)
+
+	flush := func() {
+		if inFragment {
+			nLine += "[TPL-" + strconv.Itoa(len(fragments)) + "]"
+			fragments = append(fragments, fragment)
+			fragment = ""
+			inFragment = false
+		}
+	}
+
+	for i, seg := range segs {
+		var next string
+
+		if i+1 < len(segs) {
+			next = segs[i+1].text
+		}
+
+		if !m.masks(seg.kind) {
+			flush()
+			nLine += seg.text
+			continue
+		}
+
+		switch seg.kind {
+		case templateSegment:
+			// literal tags are Smarty's even within template text
+			for _, part := range m.opts.dialect.splitLiteralTags(seg.text) {
+				if m.opts.dialect.isLiteralTag(part) {
+					flush()
+					nLine += part
+					continue
+				}
+
+				fragment += m.opts.dialect.applyPolicy(m.opts.policies[seg.kind], part, m.brace, m.opts.style)
+				inFragment = true
+			}
+
+			continue
+		case templateOpenSegment:
+			fragment += m.open(seg.text, next)
+		case templateCloseSegment:
+			fragment += m.close(seg.text)
+		case smartyTagSegment:
+			// literal tags are left for the parses to find
+			if m.opts.dialect.isLiteralTag(seg.text) {
+				flush()
+				nLine += seg.text
+				continue
+			}
+
+			fragment += seg.text
+		default:
+			fragment += m.opts.dialect.applyPolicy(m.opts.policies[seg.kind], seg.text, m.brace, m.opts.style)
+		}
+
+		inFragment = true
+	}
+
+	flush()
+
+	return nLine + eol, fragments
+}
+
+// masks tells whether segments of kind are hidden from the parses, strings
+// escaped or converted on brace parse and kept or escaped on delim parse are
+// left to them
+func (m *templateMasker) masks(kind segmentKind) bool {
+	switch kind {
+	case templateSegment, templateOpenSegment, templateCloseSegment, regexSegment, lineCommentSegment, blockCommentSegment, smartyTagSegment:
+		return true
+	case stringSegment:
+		p := m.opts.policies[stringSegment]
+
+		if m.brace {
+			return p == keepPolicy
+		}
+
+		return p == convertPolicy
+	}
+
+	return false
+}
+
+// open converts the ${ of a template expression followed by next
+func (m *templateMasker) open(text, next string) string {
+	tags, _ := m.opts.dialect.escapeStyle(m.opts.style)
+	spaced := next == "" || isSpace(next[0])
+	bare := text == "${"
+
+	var escape bool
+
+	if m.brace {
+		escape = !(m.opts.dialect.autoLiteral && spaced)
+	} else {
+		escape = !bare && m.opts.dialect.autoLiteral && !spaced
+	}
+
+	m.escaped = append(m.escaped, escape)
+
+	if escape {
+		return "$" + tags.Left
+	}
+
+	return "${"
+}
+
+// close converts the right brace ending a template expression
+func (m *templateMasker) close(text string) string {
+	var escape bool
+
+	if len(m.escaped) > 0 {
+		escape = m.escaped[len(m.escaped)-1]
+		m.escaped = m.escaped[:len(m.escaped)-1]
+	}
+
+	if !escape || (!m.brace && text == "}") {
+		return "}"
+	}
+
+	tags, _ := m.opts.dialect.escapeStyle(m.opts.style)
+
+	return tags.Right
+}
+
+// restoreTemplates puts the template literal fragments back into line
+func restoreTemplates(line string, fragments []string) string {
+	for i, v := range fragments {
+		line = strings.Replace(line, "[TPL-"+strconv.Itoa(i)+"]", v, 1)
+	}
+
+	return line
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func testMask(t *testing.T, m *templateMasker, lines, expected []string) {
+	for i, line := range lines {
+		masked, fragments := m.mask(line)
+		r := restoreTemplates(masked, fragments)
+
+		if r != expected[i] {
+			t.Fatalf("Expected: %s; got: %s", expected[i], r)
+		}
+	}
+}
+
+func TestMaskBrace(t *testing.T) {
+	lines := []string{
+		"a = `{b} ${c}`\n",
+		"a = `${ c }`\n",
+		"a = `${c ? {d: 1} : {}}`\n",
+	}
+
+	expected := []string{
+		"a = `{b} ${ldelim}c{rdelim}`\n",
+		"a = `${ldelim} c {rdelim}`\n",
+		"a = `${ldelim}c ? {d: 1} : {}{rdelim}`\n",
+	}
+
+	testMask(t, newTemplateMasker(true, defaultOptions()), lines, expected)
+
+	opts := defaultOptions()
+	opts.dialect = dialects["smarty3"]
+
+	expected[1] = "a = `${ c }`\n"
+
+	testMask(t, newTemplateMasker(true, opts), lines, expected)
+}
+
+func TestMaskDelim(t *testing.T) {
+	lines := []string{
+		"a = `{b} ${ldelim}c{rdelim}`\n",
+		"a = `${ldelim} c {rdelim}`\n",
+		"a = `${ c }`\n",
+	}
+
+	expected := []string{
+		"a = `{b} ${c}`\n",
+		"a = `${ c }`\n",
+		"a = `${ c }`\n",
+	}
+
+	testMask(t, newTemplateMasker(false, defaultOptions()), lines, expected)
+
+	opts := defaultOptions()
+	opts.dialect = dialects["smarty3"]
+
+	expected[0] = "a = `{b} ${ldelim}c{rdelim}`\n"
+
+	testMask(t, newTemplateMasker(false, opts), lines, expected)
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ------------ TREE RUNS
+
+// walkTemplates lists the templates of dialect d under root, skipping the
+// backups made with backupSuffix and the paths ignored by the
+// .smartybraceignore of root or the exclude patterns. When gitignore the
+// .gitignore files of the repository owning root are honoured as well. The
+// paths are listed as gofmt does, joined to root as given
+func walkTemplates(root string, d dialect, backupSuffix string, exclude []string, gitignore bool) ([]string, error) {
+	var files []string
+
+	exts := make(map[string]bool)
+	for _, ext := range d.exts {
+		exts[ext] = true
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// ignore rules match absolute paths, those walked start with root
+	abs := func(path string) string {
+		rel, _ := filepath.Rel(root, path)
+		return filepath.Join(absRoot, rel)
+	}
+
+	own := &ignoreMatcher{}
+	git := &ignoreMatcher{}
+
+	if err := own.addFile(filepath.Join(absRoot, ignoreFileName)); err != nil {
+		return nil, err
+	}
+
+	own.add(absRoot, exclude)
+
+	if gitignore {
+		if err := git.addParentFiles(absRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	ignored := func(path string, dir bool) bool {
+		return own.ignores(abs(path), dir) || git.ignores(abs(path), dir)
+	}
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != root && (info.Name() == ".git" || ignored(path, true)) {
+				return filepath.SkipDir
+			}
+
+			if gitignore {
+				return git.addFile(filepath.Join(abs(path), ".gitignore"))
+			}
+
+			return nil
+		}
+
+		name := info.Name()
+		ext := filepath.Ext(name)
+
+		if !exts[ext] || strings.HasSuffix(strings.TrimSuffix(name, ext), backupSuffix) {
+			return nil
+		}
+
+		if !ignored(path, false) {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// addParentFiles appends the .gitignore files found from the repository
+// root owning dir down to the parent of dir
+func (m *ignoreMatcher) addParentFiles(dir string) error {
+	var dirs []string
+
+	repo, ok := repositoryRoot(dir)
+	if !ok {
+		return nil
+	}
+
+	for d := filepath.Dir(dir); strings.HasPrefix(d, repo) && d != dir; d = filepath.Dir(d) {
+		dirs = append([]string{d}, dirs...)
+
+		if d == repo {
+			break
+		}
+	}
+
+	for _, d := range dirs {
+		if err := m.addFile(filepath.Join(d, ".gitignore")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
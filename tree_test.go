@@ -0,0 +1,54 @@
+// Copyright 2016 David Lavieri.  All rights reserved.
+// Use of this source code is governed by a MIT License
+// License that can be found in the LICENSE file.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func testWalkTemplates(t *testing.T, d dialect, exclude []string, gitignore bool, expected []string) {
+	root := "files/tree"
+	files, err := walkTemplates(root, d, "_backup", exclude, gitignore)
+
+	if err != nil {
+		t.Fatalf("Expected error to be nil; got: %s", err)
+	}
+
+	if len(files) != len(expected) {
+		t.Fatalf("Expected files: %v; got: %v", expected, files)
+	}
+
+	for i, f := range files {
+		// paths are listed joined to root as given
+		if exp := filepath.Join(root, expected[i]); f != exp {
+			t.Fatalf("Expected file: %s; got: %s", exp, f)
+		}
+	}
+}
+
+func TestWalkTemplates(t *testing.T) {
+	testWalkTemplates(t, dialects["smarty2"], nil, false, []string{
+		"index.tpl",
+		"local/draft.tpl",
+		"partials/legacy.tpl",
+		"partials/nav.tpl",
+		"themes/site.tpl",
+	})
+}
+
+func TestWalkTemplatesExclude(t *testing.T) {
+	testWalkTemplates(t, dialects["smarty2"], []string{"partials/legacy.tpl"}, true, []string{
+		"index.tpl",
+		"partials/nav.tpl",
+		"themes/site.tpl",
+	})
+}
+
+func TestWalkTemplatesLatte(t *testing.T) {
+	testWalkTemplates(t, dialects["latte"], nil, false, []string{
+		"partials/footer.latte",
+	})
+}